@@ -1,9 +1,9 @@
 package analysis
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
+	"go/token"
 
 	"golang.org/x/tools/go/callgraph"
 	"golang.org/x/tools/go/callgraph/cha"
@@ -19,6 +19,51 @@ type ProgramAnalysis struct {
 	Prog  *ssa.Program
 	Pkgs  []*ssa.Package
 	Mains []*ssa.Package
+	// Initial packages are the packages matching the load patterns
+	Initial []*ssa.Package
+	// Modules maps package paths to the module the package was loaded from. Go root packages are not included.
+	Modules map[string]*packages.Module
+	// Constraints maps file names to their build constraints, see BuildConfig. Unconstrained files are not included.
+	Constraints map[string][]string
+	// TestDoubleFiles are the files that declare mocks, fakes or stubs, generated by a mock generator, or named like one.
+	TestDoubleFiles map[string]bool
+	// PlatformBranches are the position ranges of the branches of if and switch statements on runtime.GOOS or runtime.GOARCH.
+	PlatformBranches [][2]token.Pos
+	// BuildConfig is the platform the packages were loaded for, or nil if loaded for the host.
+	BuildConfig *BuildConfig
+	// Warnings about the loaded program that did not prevent the analysis, e.g. toolchain version issues.
+	Warnings []string
+	// Entrypoints are the functions registered with known frameworks, added to the roots of the program.
+	Entrypoints []Entrypoint
+	// Tests are the functions of test files run by the go test tool, e.g. tests and benchmarks, added to the roots of the program.
+	Tests []Entrypoint
+	// Callbacks are the functions registered to be called by the runtime, e.g. finalizers, added to the roots of the program.
+	Callbacks []Entrypoint
+	// Roots are the fully qualified names of the functions to use as entry points, instead of main and init.
+	// Rapid type analysis starts from them, and call graphs of other modes are reduced to what they reach.
+	Roots []string
+	// NoInits excludes the package initializers from the roots, and removes them from the call graph,
+	// together with the functions only reachable from them.
+	NoInits bool
+	// FeatureFlags are the fully qualified names of the accessor functions of feature flags,
+	// to find the code gated by each flag of.
+	FeatureFlags []string
+	// PolymorphicTop is the number of dynamic call sites with the most callees to report, none if 0.
+	PolymorphicTop int
+	// Implementations adds the interfaces of the analyzed packages to the graph,
+	// with edges from the types in the graph that implement them.
+	Implementations bool
+	// Panics marks the functions with panics that can escape to a root of the program, and reports them.
+	Panics bool
+	// Pointer configures the pointer analysis, if that mode is used.
+	Pointer PointerOptions
+	// Fallbacks are the analyses abandoned by ComputeBudgetedCallgraph, or ComputeFallbackCallgraph,
+	// before a less precise one succeeded.
+	Fallbacks []Fallback
+	// Reachability is set when the rapid type analysis mode is computed.
+	Reachability *Reachability
+	// Unresolved is set when the static analysis mode is computed.
+	Unresolved []UnresolvedCall
 }
 
 const pkgLoadMode = packages.NeedName |
@@ -42,67 +87,194 @@ const (
 	RapidTypeAnalysis
 )
 
+// DefaultAnalysis is sound, does not need main packages, and handles any code the SSA builder accepts.
+const DefaultAnalysis = ClassHierarchyAnalysis
+
+var analysisModeNames = map[AnalysisMode]string{
+	PointerAnalysis:        "pointer",
+	StaticAnalysis:         "static",
+	ClassHierarchyAnalysis: "cha",
+	RapidTypeAnalysis:      "rta",
+}
+
+func (mode AnalysisMode) String() string {
+	if name, ok := analysisModeNames[mode]; ok {
+		return name
+	}
+	return fmt.Sprintf("AnalysisMode(%d)", uint64(mode))
+}
+
+// ParseAnalysisMode maps a mode name, as used on the command line, to an analysis mode.
+func ParseAnalysisMode(name string) (AnalysisMode, error) {
+	for mode, modeName := range analysisModeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("analysis mode not recognized: %q", name)
+}
+
+// Deprecation returns a warning for modes that are frozen upstream and may fail on newer code, or "" if none.
+func (mode AnalysisMode) Deprecation() string {
+	if mode == PointerAnalysis {
+		return "the pointer analysis package is frozen upstream, and may fail on newer Go code; consider the cha or rta mode instead"
+	}
+	return ""
+}
+
 func RunAnalysis(withTests bool, buildFlags []string, pkgPatterns []string, queryDir string) (*ProgramAnalysis, error) {
+	return RunAnalysisFor(nil, withTests, buildFlags, pkgPatterns, queryDir)
+}
+
+// RunAnalysisFor is like RunAnalysis, but loads the packages for the given platform. The host platform if nil.
+func RunAnalysisFor(config *BuildConfig, withTests bool, buildFlags []string, pkgPatterns []string, queryDir string) (*ProgramAnalysis, error) {
 	conf := &packages.Config{
 		Mode:       pkgLoadMode,
 		Tests:      withTests,
 		BuildFlags: buildFlags,
 		Dir:        queryDir,
 	}
-	pkgPatterns = append(pkgPatterns)
+	if config != nil {
+		conf.Env = config.env()
+		conf.BuildFlags = config.buildFlags(buildFlags)
+	}
 	loaded, err := packages.Load(conf, pkgPatterns...)
 	if err != nil {
-		return nil, fmt.Errorf("failed packages load: %w", err)
+		return nil, &PackageLoadError{Patterns: pkgPatterns, Err: err}
 	}
+
+	modules := make(map[string]*packages.Module)
+	constraints := make(map[string][]string)
+	testDoubleFiles := make(map[string]bool)
+	var branches [][2]token.Pos
+	packages.Visit(loaded, nil, func(p *packages.Package) {
+		if p.Module != nil {
+			modules[p.PkgPath] = p.Module
+		}
+		for _, f := range p.Syntax {
+			filename := p.Fset.File(f.Pos()).Name()
+			if c := fileConstraints(filename, f); len(c) > 0 {
+				constraints[filename] = c
+			}
+			if isTestDoubleFile(filename, f) {
+				testDoubleFiles[filename] = true
+			}
+			branches = append(branches, platformBranches(f)...)
+		}
+	})
+	var warnings []string
+	if w := toolchainWarning(modules); w != "" {
+		warnings = append(warnings, w)
+	}
+
 	prog, initialPkgs := ssautil.Packages(loaded, 0)
 
-	var errorMsg bytes.Buffer
+	failed := make(map[string][]string)
 	for i, p := range initialPkgs {
 		if p == nil && loaded[i].Name != "" {
-			errorMsg.WriteString("failed to get SSA for pkg: ")
-			errorMsg.WriteString(loaded[i].PkgPath)
-			errorMsg.WriteString("\n")
+			var msgs []string
+			for _, e := range loaded[i].Errors {
+				msgs = append(msgs, e.Error())
+			}
+			failed[loaded[i].PkgPath] = msgs
 		}
 	}
-	if errorMsg.Len() != 0 {
-		return nil, errors.New(errorMsg.String())
+	if len(failed) != 0 {
+		return nil, &SSABuildError{Packages: failed, Warnings: warnings}
 	}
 
 	prog.Build()
+	if w := reflectionWarning(prog, initialPkgs); w != "" {
+		warnings = append(warnings, w)
+	}
 
 	pkgs := prog.AllPackages()
 	mains := ssautil.MainPackages(pkgs)
 
-	return &ProgramAnalysis{
-		Prog:  prog,
-		Pkgs:  pkgs,
-		Mains: mains,
-	}, nil
+	data := &ProgramAnalysis{
+		Prog:             prog,
+		Pkgs:             pkgs,
+		Mains:            mains,
+		Initial:          initialPkgs,
+		Modules:          modules,
+		Constraints:      constraints,
+		TestDoubleFiles:  testDoubleFiles,
+		PlatformBranches: branches,
+		BuildConfig:      config,
+		Warnings:         warnings,
+	}
+	data.Entrypoints = append(data.FindEntrypoints(), cgoExports(loaded, initialPkgs)...)
+	sortEntrypoints(data.Entrypoints)
+	data.Tests = data.FindTests()
+	data.Callbacks = append(data.FindCallbacks(), linknameCallbacks(loaded, initialPkgs)...)
+	sortEntrypoints(data.Callbacks)
+	return data, nil
 }
 
-func (mode AnalysisMode) ComputeCallgraph(data *ProgramAnalysis) *callgraph.Graph {
+// ComputeCallgraph computes the call graph of the program. Failures, and crashes, of the analysis
+// are returned as *AnalysisError.
+func (mode AnalysisMode) ComputeCallgraph(data *ProgramAnalysis) (cg *callgraph.Graph, err error) {
+	// the pointer package panics on code it does not support, report that like any other failure.
+	defer func() {
+		if x := recover(); x != nil {
+			cg, err = nil, &AnalysisError{Mode: mode, Panic: x}
+		}
+	}()
+	cg, err = mode.computeCallgraph(data)
+	if err != nil {
+		return nil, &AnalysisError{Mode: mode, Err: err}
+	}
+	if len(data.Roots) > 0 && mode != RapidTypeAnalysis {
+		roots, err := data.rootFuncs()
+		if err != nil {
+			return nil, &AnalysisError{Mode: mode, Err: err}
+		}
+		pruneUnreachable(cg, roots)
+	}
+	if data.NoInits {
+		pruneInits(cg)
+	}
+	return cg, nil
+}
+
+func (mode AnalysisMode) computeCallgraph(data *ProgramAnalysis) (*callgraph.Graph, error) {
 	switch mode {
 	case PointerAnalysis:
-		ptrcfg := &pointer.Config{
-			Mains:          data.Mains,
-			BuildCallGraph: true,
+		if err := mode.CheckCompatibility(data); err != nil {
+			return nil, err
+		}
+		if len(data.Mains) == 0 {
+			return nil, errors.New("pointer analysis requires at least one main package, use the rta mode for libraries")
+		}
+		ptrcfg, err := data.Pointer.config(data)
+		if err != nil {
+			return nil, err
 		}
 		result, err := pointer.Analyze(ptrcfg)
-		if err != nil { // not a user-input problem if it fails, see Analyze doc.
-			panic(fmt.Errorf("pointer analysis failed %v", err))
+		if err != nil {
+			return nil, err
 		}
-		return result.CallGraph
+		if err := data.Pointer.report(data, result); err != nil {
+			return nil, fmt.Errorf("could not report pointer queries: %w", err)
+		}
+		return result.CallGraph, nil
 	case StaticAnalysis:
-		return static.CallGraph(data.Prog)
+		data.Unresolved = UnresolvedCalls(data)
+		return static.CallGraph(data.Prog), nil
 	case ClassHierarchyAnalysis:
-		return cha.CallGraph(data.Prog)
+		return cha.CallGraph(data.Prog), nil
 	case RapidTypeAnalysis:
-		var roots []*ssa.Function
-		for _, m := range data.Mains {
-			roots = append(roots, m.Func("init"), m.Func("main"))
+		roots, err := data.rootFuncs()
+		if err != nil {
+			return nil, err
+		}
+		if len(roots) == 0 {
+			return nil, errors.New("rapid type analysis found no roots to start from")
 		}
-		return rta.Analyze(roots, true).CallGraph
+		res := rta.Analyze(roots, true)
+		data.Reachability = newReachability(res)
+		return res.CallGraph, nil
 	default:
-		return nil
+		return nil, errors.New("unknown analysis mode")
 	}
 }
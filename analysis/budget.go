@@ -0,0 +1,73 @@
+package analysis
+
+import (
+	"time"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/static"
+)
+
+// Fallback describes an analysis attempt that failed, or did not finish within the budget,
+// after which a less precise analysis was tried.
+type Fallback struct {
+	Mode AnalysisMode
+	// Reflection is true if the attempt was a pointer analysis of reflection calls.
+	Reflection bool
+	Err        error
+}
+
+func (f *Fallback) String() string {
+	name := f.Mode.String()
+	if f.Reflection {
+		name += " with reflection"
+	}
+	return name + ": " + f.Err.Error()
+}
+
+// attempt is an analysis to try within the budget.
+type attempt struct {
+	mode       AnalysisMode
+	reflection bool
+}
+
+// reducedPrecision returns the analyses to try, from the mode itself, to less precise and cheaper analyses:
+// pointer analysis with reflection, pointer analysis without, rapid type analysis, and class hierarchy analysis.
+func (mode AnalysisMode) reducedPrecision(data *ProgramAnalysis) []attempt {
+	var out []attempt
+	switch mode {
+	case PointerAnalysis:
+		if data.Pointer.Reflection {
+			out = append(out, attempt{mode: PointerAnalysis, reflection: true})
+		}
+		out = append(out, attempt{mode: PointerAnalysis})
+		fallthrough
+	case RapidTypeAnalysis:
+		out = append(out, attempt{mode: RapidTypeAnalysis})
+		fallthrough
+	case ClassHierarchyAnalysis:
+		out = append(out, attempt{mode: ClassHierarchyAnalysis})
+	default:
+		out = append(out, attempt{mode: mode})
+	}
+	return out
+}
+
+// ComputeBudgetedCallgraph computes the call graph like ComputeCallgraph, but each analysis attempt is limited
+// to the budget. If an attempt fails, or exceeds the budget, the analysis is retried with less precision.
+// The abandoned attempts are recorded in data.Fallbacks, and the mode of the call graph is returned.
+// If all attempts fail, it returns the static call graph, together with the last failure.
+// Abandoned analyses keep running in the background.
+func (mode AnalysisMode) ComputeBudgetedCallgraph(data *ProgramAnalysis, budget time.Duration) (cg *callgraph.Graph, used AnalysisMode, failure error) {
+	for _, a := range mode.reducedPrecision(data) {
+		data.Pointer.Reflection = a.reflection
+		cg, failure = a.mode.computeWithTimeout(data, budget)
+		if failure == nil {
+			return cg, a.mode, nil
+		}
+		data.Fallbacks = append(data.Fallbacks, Fallback{Mode: a.mode, Reflection: a.reflection, Err: failure})
+	}
+	if mode == StaticAnalysis {
+		return nil, mode, failure
+	}
+	return static.CallGraph(data.Prog), StaticAnalysis, failure
+}
@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BuildConfig is a target platform, with optional build tags, to load the packages for.
+type BuildConfig struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+func (c *BuildConfig) String() string {
+	s := c.GOOS + "/" + c.GOARCH
+	if len(c.Tags) > 0 {
+		s += "," + strings.Join(c.Tags, ",")
+	}
+	return s
+}
+
+// DefaultBuildConfigs are the common platforms, to analyze platform-specific code of all of them.
+var DefaultBuildConfigs = []BuildConfig{
+	{GOOS: "linux", GOARCH: "amd64"},
+	{GOOS: "linux", GOARCH: "arm64"},
+	{GOOS: "darwin", GOARCH: "amd64"},
+	{GOOS: "windows", GOARCH: "amd64"},
+}
+
+// env returns the environment of the go tool, targeting the platform.
+func (c *BuildConfig) env() []string {
+	return append(os.Environ(), "GOOS="+c.GOOS, "GOARCH="+c.GOARCH)
+}
+
+// buildFlags adds the build tags to the flags.
+func (c *BuildConfig) buildFlags(flags []string) []string {
+	if len(c.Tags) == 0 {
+		return flags
+	}
+	return append(append([]string(nil), flags...), "-tags="+strings.Join(c.Tags, ","))
+}
+
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true, "illumos": true,
+	"ios": true, "js": true, "linux": true, "nacl": true, "netbsd": true, "openbsd": true, "plan9": true,
+	"solaris": true, "windows": true, "zos": true,
+}
+
+var knownArch = map[string]bool{
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true, "arm64be": true,
+	"loong64": true, "mips": true, "mipsle": true, "mips64": true, "mips64le": true, "mips64p32": true,
+	"mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true, "riscv": true, "riscv64": true,
+	"s390": true, "s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+}
+
+// fileConstraints returns the build constraints of a file: the GOOS and GOARCH of its name suffix,
+// e.g. "linux" and "amd64" for "file_linux_amd64.go", and the expressions of its build constraint comments.
+func fileConstraints(filename string, f *ast.File) []string {
+	var out []string
+
+	name := strings.TrimSuffix(filepath.Base(filename), ".go")
+	name = strings.TrimSuffix(name, "_test")
+	// like the go tool, the part before the first underscore is not a constraint.
+	if i := strings.Index(name, "_"); i >= 0 {
+		parts := strings.Split(name[i+1:], "_")
+		n := len(parts)
+		if n >= 2 && knownOS[parts[n-2]] && knownArch[parts[n-1]] {
+			out = append(out, parts[n-2], parts[n-1])
+		} else if knownOS[parts[n-1]] || knownArch[parts[n-1]] {
+			out = append(out, parts[n-1])
+		}
+	}
+
+	// "//go:build" lines replace the older "// +build" lines, if both are present.
+	var goBuild, plusBuild []string
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, c := range group.List {
+			if expr := strings.TrimPrefix(c.Text, "//go:build "); expr != c.Text {
+				goBuild = append(goBuild, strings.TrimSpace(expr))
+			} else if expr := strings.TrimPrefix(c.Text, "// +build "); expr != c.Text {
+				plusBuild = append(plusBuild, strings.TrimSpace(expr))
+			}
+		}
+	}
+	if len(goBuild) > 0 {
+		return append(out, goBuild...)
+	}
+	return append(out, plusBuild...)
+}
+
+// isPlatformExpr tells if the expression refers to runtime.GOOS or runtime.GOARCH.
+func isPlatformExpr(e ast.Expr) bool {
+	found := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Name == "runtime" && (sel.Sel.Name == "GOOS" || sel.Sel.Name == "GOARCH") {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// platformBranches returns the position ranges of the branches of the if and switch statements on runtime.GOOS
+// or runtime.GOARCH in the file. These conditions are constant, and folded away in SSA, so the syntax is checked instead.
+func platformBranches(f *ast.File) [][2]token.Pos {
+	var out [][2]token.Pos
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.IfStmt:
+			if isPlatformExpr(s.Cond) {
+				out = append(out, [2]token.Pos{s.Body.Pos(), s.End()})
+			}
+		case *ast.SwitchStmt:
+			if s.Tag != nil && isPlatformExpr(s.Tag) {
+				out = append(out, [2]token.Pos{s.Body.Pos(), s.End()})
+			}
+		}
+		return true
+	})
+	return out
+}
@@ -0,0 +1,83 @@
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const hashLoadMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedImports |
+	packages.NeedDeps |
+	packages.NeedModule
+
+// immutableModule tells if the module contents are identified by its path and version.
+func immutableModule(m *packages.Module) bool {
+	return m != nil && !m.Main && m.Version != "" && m.Replace == nil
+}
+
+// ContentHash identifies the contents of the program, and all its dependencies, to cache analysis results with.
+// Packages are only listed, not type-checked, which is fast compared to a full analysis.
+// Dependencies of released module versions are identified by their module version, other packages by their file contents.
+func ContentHash(withTests bool, buildFlags []string, pkgPatterns []string, queryDir string) (string, error) {
+	return ContentHashFor(nil, withTests, buildFlags, pkgPatterns, queryDir)
+}
+
+// ContentHashFor is like ContentHash, but for the files of the given platform. The host platform if nil.
+func ContentHashFor(config *BuildConfig, withTests bool, buildFlags []string, pkgPatterns []string, queryDir string) (string, error) {
+	conf := &packages.Config{
+		Mode:       hashLoadMode,
+		Tests:      withTests,
+		BuildFlags: buildFlags,
+		Dir:        queryDir,
+	}
+	if config != nil {
+		conf.Env = config.env()
+		conf.BuildFlags = config.buildFlags(buildFlags)
+	}
+	loaded, err := packages.Load(conf, pkgPatterns...)
+	if err != nil {
+		return "", &PackageLoadError{Patterns: pkgPatterns, Err: err}
+	}
+	var all []*packages.Package
+	packages.Visit(loaded, nil, func(p *packages.Package) {
+		all = append(all, p)
+	})
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "toolchain %s\n", ToolchainVersion())
+	for _, p := range all {
+		_, _ = fmt.Fprintf(h, "package %s\n", p.ID)
+		if immutableModule(p.Module) {
+			_, _ = fmt.Fprintf(h, "module %s@%s\n", p.Module.Path, p.Module.Version)
+			continue
+		}
+		files := append(append([]string{}, p.GoFiles...), p.OtherFiles...)
+		for _, name := range files {
+			if err := hashFile(h, name); err != nil {
+				return "", err
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(h io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("could not hash file: %w", err)
+	}
+	defer f.Close()
+	_, _ = fmt.Fprintf(h, "file %s\n", name)
+	_, err = io.Copy(h, f)
+	return err
+}
@@ -0,0 +1,198 @@
+package analysis
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// callbackRegistrars register the functions passed to them, to be called by the runtime instead of the program.
+var callbackRegistrars = []registrar{
+	{pkg: "runtime", kind: "finalizer", names: []string{"SetFinalizer"}},
+	{pkg: "time", kind: "timer", names: []string{"AfterFunc"}},
+}
+
+// signalReceivers finds the functions that receive from the channel, following it through conversions,
+// closures and static calls. Channels stored in variables are not followed.
+func signalReceivers(v ssa.Value, seen map[ssa.Value]bool) []*ssa.Function {
+	if seen[v] {
+		return nil
+	}
+	seen[v] = true
+	refs := v.Referrers()
+	if refs == nil {
+		return nil
+	}
+	var out []*ssa.Function
+	for _, ref := range *refs {
+		switch r := ref.(type) {
+		case *ssa.UnOp:
+			if r.Op == token.ARROW {
+				out = append(out, r.Parent())
+			}
+		case *ssa.Select:
+			for _, st := range r.States {
+				if st.Dir == types.RecvOnly && st.Chan == v {
+					out = append(out, r.Parent())
+				}
+			}
+		case *ssa.ChangeType:
+			out = append(out, signalReceivers(r, seen)...)
+		case *ssa.MakeClosure:
+			fn, ok := r.Fn.(*ssa.Function)
+			if !ok {
+				continue
+			}
+			for i, b := range r.Bindings {
+				if b == v && i < len(fn.FreeVars) {
+					out = append(out, signalReceivers(fn.FreeVars[i], seen)...)
+				}
+			}
+		case ssa.CallInstruction:
+			common := r.Common()
+			callee := common.StaticCallee()
+			if callee == nil {
+				continue
+			}
+			for i, a := range common.Args {
+				if a == v && i < len(callee.Params) {
+					out = append(out, signalReceivers(callee.Params[i], seen)...)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// registeredCallbacks returns the functions registered by the call, if it calls a callback registrar,
+// or the receivers of the channel it passes to signal.Notify.
+func (data *ProgramAnalysis) registeredCallbacks(common *ssa.CallCommon) []Entrypoint {
+	pkg, name, ok := calleeID(common)
+	if !ok {
+		return nil
+	}
+	var out []Entrypoint
+	if pkg == "os/signal" && name == "Notify" && len(common.Args) > 0 {
+		seen := make(map[*ssa.Function]bool)
+		for _, fn := range signalReceivers(common.Args[0], make(map[ssa.Value]bool)) {
+			if !seen[fn] {
+				seen[fn] = true
+				out = append(out, Entrypoint{Func: fn, Kind: "signal"})
+			}
+		}
+		return out
+	}
+	for i := range callbackRegistrars {
+		r := &callbackRegistrars[i]
+		if !r.matches(pkg, name) {
+			continue
+		}
+		for _, a := range common.Args {
+			for _, h := range data.handlerFuncs(a, r.handlerMethod) {
+				out = append(out, Entrypoint{Func: h, Kind: r.kind})
+			}
+		}
+	}
+	return out
+}
+
+// linknames returns the local and remote names of the //go:linkname directives of the file.
+// The remote name is empty if the directive only makes the local name accessible.
+func linknames(f *ast.File) (local []string, remote []string) {
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			fields := strings.Fields(c.Text)
+			if len(fields) < 2 || fields[0] != "//go:linkname" {
+				continue
+			}
+			local = append(local, fields[1])
+			if len(fields) > 2 {
+				remote = append(remote, fields[2])
+			} else {
+				remote = append(remote, "")
+			}
+		}
+	}
+	return local, remote
+}
+
+// linknameCallbacks returns the functions of the initial packages with a //go:linkname directive.
+// These may be called by name from other packages, e.g. the runtime, invisible to the call graph.
+func linknameCallbacks(loaded []*packages.Package, initial []*ssa.Package) []Entrypoint {
+	var out []Entrypoint
+	for i, p := range loaded {
+		if initial[i] == nil {
+			continue
+		}
+		for _, f := range p.Syntax {
+			local, remote := linknames(f)
+			for j, name := range local {
+				if fn := initial[i].Func(name); fn != nil {
+					out = append(out, Entrypoint{Func: fn, Kind: "linkname", Name: remote[j]})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// cgoExports returns the functions of the initial packages with an //export directive, called by C code
+// through cgo, e.g. when the package is built as shared library. The name is the exported C name.
+func cgoExports(loaded []*packages.Package, initial []*ssa.Package) []Entrypoint {
+	var out []Entrypoint
+	for i, p := range loaded {
+		if initial[i] == nil {
+			continue
+		}
+		for _, f := range p.Syntax {
+			for _, decl := range f.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Recv != nil || fd.Doc == nil {
+					continue
+				}
+				for _, c := range fd.Doc.List {
+					fields := strings.Fields(c.Text)
+					if len(fields) < 2 || fields[0] != "//export" {
+						continue
+					}
+					if fn := initial[i].Func(fd.Name.Name); fn != nil {
+						out = append(out, Entrypoint{Func: fn, Kind: "cgo-export", Name: fields[1]})
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// FindCallbacks finds the functions that the analyzed (initial) packages register to be called by the runtime:
+// finalizers, timer functions, and receivers of signal channels.
+// Functions with a //go:linkname directive are found by RunAnalysis, from the syntax of the packages.
+func (data *ProgramAnalysis) FindCallbacks() []Entrypoint {
+	initial := data.initialSet()
+	var out []Entrypoint
+	for fn := range ssautil.AllFunctions(data.Prog) {
+		if !initial[fn.Pkg] {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if site, ok := instr.(ssa.CallInstruction); ok {
+					out = append(out, data.registeredCallbacks(site.Common())...)
+				}
+			}
+		}
+	}
+	sortEntrypoints(out)
+	return out
+}
+
+// CallbackLabels maps each registered callback to the descriptions of its registrations, e.g. "finalizer".
+func (data *ProgramAnalysis) CallbackLabels() map[*ssa.Function][]string {
+	return entrypointLabels(data.Callbacks)
+}
@@ -0,0 +1,83 @@
+package analysis
+
+import (
+	"fmt"
+	"go/build"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// genericsMinor is the minor Go version that introduced generics, which the pointer analysis does not support.
+const genericsMinor = 18
+
+// ToolchainVersion is the Go version gocyto was built with, e.g. "go1.14".
+// Its go/types and go/parser versions determine what language features can be loaded.
+func ToolchainVersion() string {
+	tags := build.Default.ReleaseTags
+	if len(tags) == 0 {
+		return ""
+	}
+	return tags[len(tags)-1]
+}
+
+// goMinor parses the minor version of a Go version like "go1.14" or "1.14.2".
+func goMinor(v string) (int, bool) {
+	v = strings.TrimPrefix(v, "go")
+	if !strings.HasPrefix(v, "1.") {
+		return 0, false
+	}
+	v = v[2:]
+	if dot := strings.Index(v, "."); dot >= 0 {
+		v = v[:dot]
+	}
+	minor, err := strconv.Atoi(v)
+	return minor, err == nil
+}
+
+// LanguageVersion is the highest Go version required by the go.mod of the analyzed (main) modules,
+// or "" if unknown.
+func (data *ProgramAnalysis) LanguageVersion() string {
+	return languageVersion(data.Modules)
+}
+
+func languageVersion(modules map[string]*packages.Module) string {
+	best, bestMinor := "", -1
+	for _, m := range modules {
+		if !m.Main || m.GoVersion == "" {
+			continue
+		}
+		if minor, ok := goMinor(m.GoVersion); ok && minor > bestMinor {
+			best, bestMinor = m.GoVersion, minor
+		}
+	}
+	return best
+}
+
+// toolchainWarning reports if the analyzed modules require a newer Go version than the toolchain, or "" if not.
+func toolchainWarning(modules map[string]*packages.Module) string {
+	lang := languageVersion(modules)
+	toolchain := ToolchainVersion()
+	langMinor, ok := goMinor(lang)
+	if !ok {
+		return ""
+	}
+	toolMinor, ok := goMinor(toolchain)
+	if !ok || langMinor <= toolMinor {
+		return ""
+	}
+	return fmt.Sprintf("analyzed module requires go %s, but gocyto was built with %s: newer language features may fail to load", lang, toolchain)
+}
+
+// CheckCompatibility returns an error if the mode cannot analyze the program, suggesting an alternative.
+func (mode AnalysisMode) CheckCompatibility(data *ProgramAnalysis) error {
+	if mode != PointerAnalysis {
+		return nil
+	}
+	lang := data.LanguageVersion()
+	if minor, ok := goMinor(lang); ok && minor >= genericsMinor {
+		return fmt.Errorf("pointer analysis does not support generics, and the analyzed module uses go %s: use the cha or rta mode instead", lang)
+	}
+	return nil
+}
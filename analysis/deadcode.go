@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// DeadFunc is a function that is not reachable from any root of the program.
+type DeadFunc struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// FindDeadCode lists the declared functions and methods of the analyzed (initial) packages,
+// that the call graph does not reach from the roots of the program. Closures are not listed separately.
+// The precision depends on the analysis: dynamic calls resolved to too many callees hide dead code.
+func FindDeadCode(data *ProgramAnalysis, cg *callgraph.Graph) ([]DeadFunc, error) {
+	reachable, err := data.reachableFuncs(cg)
+	if err != nil {
+		return nil, err
+	}
+
+	initial := data.initialSet()
+	var out []DeadFunc
+	for fn := range ssautil.AllFunctions(data.Prog) {
+		if !initial[fn.Pkg] || fn.Parent() != nil || fn.Synthetic != "" || !fn.Pos().IsValid() || reachable[fn] {
+			continue
+		}
+		pos := data.Prog.Fset.Position(fn.Pos())
+		out = append(out, DeadFunc{Func: fn.String(), File: pos.Filename, Line: pos.Line})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out, nil
+}
+
+// reachableFuncs returns the functions the call graph reaches from the roots of the program.
+func (data *ProgramAnalysis) reachableFuncs(cg *callgraph.Graph) (map[*ssa.Function]bool, error) {
+	roots, err := data.rootFuncs()
+	if err != nil {
+		return nil, err
+	}
+	reachable := make(map[*ssa.Function]bool)
+	var visit func(n *callgraph.Node)
+	visit = func(n *callgraph.Node) {
+		if reachable[n.Func] {
+			return
+		}
+		reachable[n.Func] = true
+		for _, e := range n.Out {
+			visit(e.Callee)
+		}
+	}
+	for _, fn := range roots {
+		if n, ok := cg.Nodes[fn]; ok {
+			visit(n)
+		}
+	}
+	return reachable, nil
+}
+
+// WriteDeadCodeText writes each dead function on a line, prefixed with its position.
+func WriteDeadCodeText(w io.Writer, dead []DeadFunc) error {
+	for _, d := range dead {
+		if _, err := fmt.Fprintf(w, "%s:%d: %s\n", d.File, d.Line, d.Func); err != nil {
+			return err
+		}
+	}
+	return nil
+}
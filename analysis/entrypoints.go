@@ -0,0 +1,427 @@
+package analysis
+
+import (
+	"go/constant"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Entrypoint is a function that is invoked by a framework, rather than called by the program itself.
+type Entrypoint struct {
+	Func *ssa.Function
+	// Kind of entry point, e.g. "http".
+	Kind string
+	// Name identifies the entry point within its kind, e.g. the route, if known.
+	Name string
+}
+
+func (ep *Entrypoint) String() string {
+	if ep.Name == "" {
+		return ep.Kind
+	}
+	return ep.Kind + " " + ep.Name
+}
+
+// registrar is a function, or method, that registers the functions passed to it as entry points.
+type registrar struct {
+	// pkg is the import path of the package, major version suffixes like "/v4" also match.
+	pkg string
+	// names of the functions or methods.
+	names []string
+	kind  string
+	// handlerMethod is called on handler objects, registered instead of functions. Optional.
+	handlerMethod string
+}
+
+var httpVerbs = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "CONNECT", "TRACE"}
+
+var registrars = []registrar{
+	{pkg: "net/http", kind: "http", handlerMethod: "ServeHTTP",
+		names: []string{"Handle", "HandleFunc", "ListenAndServe", "ListenAndServeTLS"}},
+	{pkg: "github.com/gin-gonic/gin", kind: "http",
+		names: append([]string{"Any", "Handle", "Use", "NoRoute", "NoMethod"}, httpVerbs...)},
+	{pkg: "github.com/labstack/echo", kind: "http",
+		names: append([]string{"Any", "Add", "Match", "Use", "Pre"}, httpVerbs...)},
+	{pkg: "github.com/go-chi/chi", kind: "http", handlerMethod: "ServeHTTP",
+		names: []string{"Get", "Post", "Put", "Patch", "Delete", "Head", "Options", "Connect", "Trace",
+			"Handle", "HandleFunc", "Method", "MethodFunc", "Mount", "NotFound", "MethodNotAllowed", "Use", "With"}},
+}
+
+// matchesPkg tells if the import path is the base path, or a major version of it.
+func matchesPkg(path string, base string) bool {
+	if path == base {
+		return true
+	}
+	if !strings.HasPrefix(path, base+"/v") {
+		return false
+	}
+	for _, c := range path[len(base)+2:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// calleeID returns the package path and name of the function or interface method a call site calls, if known.
+func calleeID(common *ssa.CallCommon) (pkg string, name string, ok bool) {
+	if common.IsInvoke() {
+		if common.Method.Pkg() == nil {
+			return "", "", false
+		}
+		return common.Method.Pkg().Path(), common.Method.Name(), true
+	}
+	fn := common.StaticCallee()
+	if fn == nil || fn.Object() == nil || fn.Object().Pkg() == nil {
+		return "", "", false
+	}
+	return fn.Object().Pkg().Path(), fn.Name(), true
+}
+
+func (r *registrar) matches(pkg string, name string) bool {
+	if !matchesPkg(pkg, r.pkg) {
+		return false
+	}
+	for _, n := range r.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// entrypointName describes the registration from its string constant arguments, e.g. a route,
+// prefixed with the HTTP verb if the registering method is named after one.
+func entrypointName(name string, args []ssa.Value) string {
+	var parts []string
+	for _, v := range httpVerbs {
+		if strings.ToUpper(name) == v {
+			parts = append(parts, v)
+		}
+	}
+	for _, a := range args {
+		if c, ok := a.(*ssa.Const); ok && c.Value != nil && c.Value.Kind() == constant.String {
+			parts = append(parts, constant.StringVal(c.Value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// handlerFuncs resolves the functions a value passed to a registrar may refer to.
+// Handler objects are resolved to their handler method, if the registrar has one.
+func (data *ProgramAnalysis) handlerFuncs(v ssa.Value, handlerMethod string) []*ssa.Function {
+	switch v := v.(type) {
+	case *ssa.Function:
+		return []*ssa.Function{v}
+	case *ssa.MakeClosure:
+		if fn, ok := v.Fn.(*ssa.Function); ok {
+			return []*ssa.Function{fn}
+		}
+	case *ssa.ChangeType:
+		return data.handlerFuncs(v.X, handlerMethod)
+	case *ssa.MakeInterface:
+		if _, ok := v.X.Type().Underlying().(*types.Signature); ok || handlerMethod == "" {
+			return data.handlerFuncs(v.X, handlerMethod)
+		}
+		if sel := data.Prog.MethodSets.MethodSet(v.X.Type()).Lookup(nil, handlerMethod); sel != nil {
+			if fn := data.Prog.MethodValue(sel); fn != nil {
+				return []*ssa.Function{fn}
+			}
+		}
+	case *ssa.Slice:
+		// variadic arguments are stored in an array, and passed as slice of it.
+		var out []*ssa.Function
+		if refs := v.X.Referrers(); refs != nil {
+			for _, ref := range *refs {
+				idx, ok := ref.(*ssa.IndexAddr)
+				if !ok || idx.Referrers() == nil {
+					continue
+				}
+				for _, r := range *idx.Referrers() {
+					if store, ok := r.(*ssa.Store); ok && store.Addr == ssa.Value(idx) {
+						out = append(out, data.handlerFuncs(store.Val, handlerMethod)...)
+					}
+				}
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// registeredEntrypoints returns the functions passed to the call, if it calls a registrar.
+func (data *ProgramAnalysis) registeredEntrypoints(common *ssa.CallCommon) []Entrypoint {
+	pkg, name, ok := calleeID(common)
+	if !ok {
+		return nil
+	}
+	var out []Entrypoint
+	for i := range registrars {
+		r := &registrars[i]
+		if !r.matches(pkg, name) {
+			continue
+		}
+		epName := entrypointName(name, common.Args)
+		for _, a := range common.Args {
+			for _, h := range data.handlerFuncs(a, r.handlerMethod) {
+				out = append(out, Entrypoint{Func: h, Kind: r.kind, Name: epName})
+			}
+		}
+	}
+	return out
+}
+
+// commandStruct is a struct type of a CLI framework, of which the function fields are run by the framework.
+type commandStruct struct {
+	// pkg is the import path of the package, major version suffixes like "/v2" also match.
+	pkg      string
+	typeName string
+	// nameField is the string field that names the command.
+	nameField string
+	// runFields run the command itself.
+	runFields []string
+	// hookFields run before or after the command, their entry points are named after the hook too.
+	hookFields []string
+}
+
+var commandStructs = []commandStruct{
+	{pkg: "github.com/spf13/cobra", typeName: "Command", nameField: "Use",
+		runFields: []string{"Run", "RunE"},
+		hookFields: []string{"PreRun", "PreRunE", "PostRun", "PostRunE",
+			"PersistentPreRun", "PersistentPreRunE", "PersistentPostRun", "PersistentPostRunE"}},
+	{pkg: "github.com/urfave/cli", typeName: "Command", nameField: "Name",
+		runFields: []string{"Action"}, hookFields: []string{"Before", "After"}},
+	{pkg: "github.com/urfave/cli", typeName: "App", nameField: "Name",
+		runFields: []string{"Action"}, hookFields: []string{"Before", "After"}},
+}
+
+// fieldOf returns the named struct type and the field name the address points to.
+func fieldOf(fa *ssa.FieldAddr) (*types.Named, string, bool) {
+	ptr, ok := fa.X.Type().Underlying().(*types.Pointer)
+	if !ok {
+		return nil, "", false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok {
+		return nil, "", false
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, "", false
+	}
+	return named, st.Field(fa.Field).Name(), true
+}
+
+// commandName finds the constant string stored in the name field of the same command struct.
+// For cobra commands only the first word of the usage line is used.
+func commandName(cmd ssa.Value, nameField string) string {
+	refs := cmd.Referrers()
+	if refs == nil {
+		return ""
+	}
+	for _, ref := range *refs {
+		fa, ok := ref.(*ssa.FieldAddr)
+		if !ok || fa.Referrers() == nil {
+			continue
+		}
+		if _, field, ok := fieldOf(fa); !ok || field != nameField {
+			continue
+		}
+		for _, r := range *fa.Referrers() {
+			store, ok := r.(*ssa.Store)
+			if !ok || store.Addr != ssa.Value(fa) {
+				continue
+			}
+			if c, ok := store.Val.(*ssa.Const); ok && c.Value != nil && c.Value.Kind() == constant.String {
+				if words := strings.Fields(constant.StringVal(c.Value)); len(words) > 0 {
+					return words[0]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// commandEntrypoints returns the functions stored in the command struct field, if it is run by a CLI framework.
+// The entry points are named after the command.
+func (data *ProgramAnalysis) commandEntrypoints(store *ssa.Store) []Entrypoint {
+	fa, ok := store.Addr.(*ssa.FieldAddr)
+	if !ok {
+		return nil
+	}
+	named, field, ok := fieldOf(fa)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil
+	}
+	for i := range commandStructs {
+		c := &commandStructs[i]
+		if named.Obj().Name() != c.typeName || !matchesPkg(named.Obj().Pkg().Path(), c.pkg) {
+			continue
+		}
+		var parts []string
+		if name := commandName(fa.X, c.nameField); name != "" {
+			parts = append(parts, name)
+		}
+		run := false
+		for _, f := range c.runFields {
+			run = run || f == field
+		}
+		if !run {
+			hook := false
+			for _, f := range c.hookFields {
+				hook = hook || f == field
+			}
+			if !hook {
+				continue
+			}
+			parts = append(parts, field)
+		}
+		var out []Entrypoint
+		for _, h := range data.handlerFuncs(store.Val, "") {
+			out = append(out, Entrypoint{Func: h, Kind: "cli", Name: strings.Join(parts, " ")})
+		}
+		return out
+	}
+	return nil
+}
+
+// grpcPkg is the import path of the gRPC package, used by generated service code.
+const grpcPkg = "google.golang.org/grpc"
+
+// grpcServices finds the server interfaces of generated gRPC code, mapped to their service name.
+// These are the interfaces accepted by the generated RegisterXServer(s *grpc.Server, srv XServer) functions.
+func (data *ProgramAnalysis) grpcServices() map[*types.Named]string {
+	out := make(map[*types.Named]string)
+	for _, p := range data.Prog.AllPackages() {
+		for name, mem := range p.Members {
+			fn, ok := mem.(*ssa.Function)
+			if !ok || !strings.HasPrefix(name, "Register") || !strings.HasSuffix(name, "Server") {
+				continue
+			}
+			params := fn.Signature.Params()
+			if params.Len() != 2 {
+				continue
+			}
+			server := params.At(0).Type()
+			if ptr, ok := server.(*types.Pointer); ok {
+				server = ptr.Elem()
+			}
+			if named, ok := server.(*types.Named); !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != grpcPkg {
+				continue
+			}
+			iface, ok := params.At(1).Type().(*types.Named)
+			if !ok || !types.IsInterface(iface) {
+				continue
+			}
+			out[iface] = strings.TrimSuffix(iface.Obj().Name(), "Server")
+		}
+	}
+	return out
+}
+
+// grpcEntrypoints finds the methods of types in the initial packages that implement a gRPC service.
+// The generated Unimplemented types, and the methods promoted from them, are skipped.
+func (data *ProgramAnalysis) grpcEntrypoints(initial map[*ssa.Package]bool) []Entrypoint {
+	services := data.grpcServices()
+	if len(services) == 0 {
+		return nil
+	}
+	var out []Entrypoint
+	for p := range initial {
+		for name, mem := range p.Members {
+			t, ok := mem.(*ssa.Type)
+			if !ok || strings.HasPrefix(name, "Unimplemented") || types.IsInterface(t.Type()) {
+				continue
+			}
+			ptr := types.NewPointer(t.Type())
+			for iface, service := range services {
+				it := iface.Underlying().(*types.Interface)
+				if !types.Implements(ptr, it) {
+					continue
+				}
+				mset := data.Prog.MethodSets.MethodSet(ptr)
+				for i := 0; i < it.NumMethods(); i++ {
+					m := it.Method(i)
+					if !m.Exported() {
+						continue
+					}
+					sel := mset.Lookup(m.Pkg(), m.Name())
+					if sel == nil {
+						continue
+					}
+					fn := data.Prog.MethodValue(sel)
+					if fn == nil || fn.Synthetic != "" {
+						continue
+					}
+					out = append(out, Entrypoint{Func: fn, Kind: "grpc", Name: service + "/" + m.Name()})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// FindEntrypoints finds the functions that the analyzed (initial) packages register with a known framework,
+// e.g. HTTP handlers of net/http, gin, echo and chi, the implementations of gRPC services,
+// and the command functions of cobra and urfave/cli.
+func (data *ProgramAnalysis) FindEntrypoints() []Entrypoint {
+	initial := data.initialSet()
+	var out []Entrypoint
+	for fn := range ssautil.AllFunctions(data.Prog) {
+		if !initial[fn.Pkg] {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				switch instr := instr.(type) {
+				case ssa.CallInstruction:
+					out = append(out, data.registeredEntrypoints(instr.Common())...)
+				case *ssa.Store:
+					out = append(out, data.commandEntrypoints(instr)...)
+				}
+			}
+		}
+	}
+	out = append(out, data.grpcEntrypoints(initial)...)
+	sortEntrypoints(out)
+	return out
+}
+
+// initialSet returns the initial packages as set.
+func (data *ProgramAnalysis) initialSet() map[*ssa.Package]bool {
+	initial := make(map[*ssa.Package]bool)
+	for _, p := range data.Initial {
+		if p != nil {
+			initial[p] = true
+		}
+	}
+	return initial
+}
+
+// sortEntrypoints sorts by function name, and then by description.
+func sortEntrypoints(eps []Entrypoint) {
+	sort.Slice(eps, func(i, j int) bool {
+		if a, b := eps[i].Func.String(), eps[j].Func.String(); a != b {
+			return a < b
+		}
+		return eps[i].String() < eps[j].String()
+	})
+}
+
+func entrypointLabels(eps []Entrypoint) map[*ssa.Function][]string {
+	out := make(map[*ssa.Function][]string)
+	for i := range eps {
+		ep := &eps[i]
+		out[ep.Func] = append(out[ep.Func], ep.String())
+	}
+	return out
+}
+
+// EntrypointLabels maps each entry point function to the descriptions of its registrations, e.g. "http GET /users".
+func (data *ProgramAnalysis) EntrypointLabels() map[*ssa.Function][]string {
+	return entrypointLabels(data.Entrypoints)
+}
@@ -0,0 +1,93 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Error kinds, to check for with errors.Is. The concrete errors carry the details.
+var (
+	ErrPackageLoad    = errors.New("failed packages load")
+	ErrSSABuild       = errors.New("failed SSA build")
+	ErrAnalysisFailed = errors.New("call graph analysis failed")
+)
+
+// PackageLoadError is returned when the packages could not be listed or loaded by the Go tool.
+type PackageLoadError struct {
+	Patterns []string
+	Err      error
+}
+
+func (e *PackageLoadError) Error() string {
+	return fmt.Sprintf("%v %s: %v", ErrPackageLoad, strings.Join(e.Patterns, " "), e.Err)
+}
+
+func (e *PackageLoadError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PackageLoadError) Is(target error) bool {
+	return target == ErrPackageLoad
+}
+
+// SSABuildError is returned when some of the loaded packages could not be converted to SSA form,
+// usually because of type errors.
+type SSABuildError struct {
+	// Packages maps the import path of each failed package to the errors reported when loading it.
+	Packages map[string][]string
+	// Warnings about the program that may explain the failure, e.g. toolchain version issues.
+	Warnings []string
+}
+
+func (e *SSABuildError) Error() string {
+	var buf strings.Builder
+	buf.WriteString(ErrSSABuild.Error())
+	paths := make([]string, 0, len(e.Packages))
+	for path := range e.Packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		buf.WriteString("\nfailed to get SSA for pkg: ")
+		buf.WriteString(path)
+		for _, msg := range e.Packages[path] {
+			buf.WriteString("\n\t")
+			buf.WriteString(msg)
+		}
+	}
+	for _, w := range e.Warnings {
+		buf.WriteString("\n")
+		buf.WriteString(w)
+	}
+	return buf.String()
+}
+
+func (e *SSABuildError) Is(target error) bool {
+	return target == ErrSSABuild
+}
+
+// AnalysisError is returned when the call graph could not be computed with the analysis mode,
+// another mode may still succeed.
+type AnalysisError struct {
+	Mode AnalysisMode
+	Err  error
+	// Panic is the value the analysis panicked with, if it crashed rather than failed.
+	Panic interface{}
+}
+
+func (e *AnalysisError) Error() string {
+	if e.Panic != nil {
+		return fmt.Sprintf("%s analysis crashed: %v", e.Mode, e.Panic)
+	}
+	return fmt.Sprintf("%s analysis failed: %v", e.Mode, e.Err)
+}
+
+func (e *AnalysisError) Unwrap() error {
+	return e.Err
+}
+
+func (e *AnalysisError) Is(target error) bool {
+	return target == ErrAnalysisFailed
+}
@@ -0,0 +1,114 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// ModeEstimate is a heuristic estimate of the cost of an analysis mode,
+// only meant to compare modes, and to spot runs that are not feasible.
+type ModeEstimate struct {
+	Mode   string        `json:"mode"`
+	Edges  int           `json:"edges"`
+	Time   time.Duration `json:"time"`
+	Memory uint64        `json:"memory"`
+}
+
+type CostEstimate struct {
+	Packages         int            `json:"packages"`
+	Functions        int            `json:"functions"`
+	Instructions     int            `json:"instructions"`
+	CallSites        int            `json:"call_sites"`
+	DynamicCallSites int            `json:"dynamic_call_sites"`
+	Modes            []ModeEstimate `json:"modes"`
+}
+
+// modeCost is the rough cost of each mode per SSA instruction, and the share of the dynamic call candidates
+// that typically remain after the analysis.
+var modeCost = []struct {
+	mode       AnalysisMode
+	perInstr   time.Duration
+	memPerInst uint64
+	dynShare   float64
+}{
+	{StaticAnalysis, 200 * time.Nanosecond, 50, 0},
+	{ClassHierarchyAnalysis, 500 * time.Nanosecond, 100, 1},
+	{RapidTypeAnalysis, 2 * time.Microsecond, 300, 0.5},
+	{PointerAnalysis, 50 * time.Microsecond, 4000, 0.25},
+}
+
+// EstimateCost counts the functions and call sites of the loaded program, to estimate the cost of each mode
+// before running it. Dynamic calls are estimated to have a candidate callee for every method of the same name,
+// or every function of the same signature.
+func EstimateCost(data *ProgramAnalysis) *CostEstimate {
+	est := &CostEstimate{Packages: len(data.Pkgs)}
+	funcs := ssautil.AllFunctions(data.Prog)
+	methodsByName := make(map[string]int)
+	funcsBySig := make(map[string]int)
+	for fn := range funcs {
+		if fn.Signature.Recv() != nil {
+			methodsByName[fn.Name()]++
+		} else {
+			funcsBySig[fn.Signature.String()]++
+		}
+	}
+	staticEdges, dynamicEdges := 0, 0
+	for fn := range funcs {
+		est.Functions++
+		for _, b := range fn.Blocks {
+			est.Instructions += len(b.Instrs)
+			for _, instr := range b.Instrs {
+				site, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+				common := site.Common()
+				if _, ok := common.Value.(*ssa.Builtin); ok {
+					continue
+				}
+				est.CallSites++
+				if common.StaticCallee() != nil {
+					staticEdges++
+					continue
+				}
+				est.DynamicCallSites++
+				if common.IsInvoke() {
+					dynamicEdges += methodsByName[common.Method.Name()]
+				} else {
+					dynamicEdges += funcsBySig[common.Signature().String()]
+				}
+			}
+		}
+	}
+	for _, c := range modeCost {
+		est.Modes = append(est.Modes, ModeEstimate{
+			Mode:   c.mode.String(),
+			Edges:  staticEdges + int(float64(dynamicEdges)*c.dynShare),
+			Time:   time.Duration(est.Instructions) * c.perInstr,
+			Memory: uint64(est.Instructions) * c.memPerInst,
+		})
+	}
+	return est
+}
+
+func (est *CostEstimate) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "packages: %d\nfunctions: %d\ninstructions: %d\ncall sites: %d (%d dynamic)\n\n",
+		est.Packages, est.Functions, est.Instructions, est.CallSites, est.DynamicCallSites)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "rough estimates per mode, in addition to loading the packages:"); err != nil {
+		return err
+	}
+	for _, m := range est.Modes {
+		if _, err := fmt.Fprintf(w, "  %-8s edges: %-10d time: %-12s memory: %d MiB\n",
+			m.Mode, m.Edges, m.Time.Round(time.Second), m.Memory>>20); err != nil {
+			return err
+		}
+	}
+	return nil
+}
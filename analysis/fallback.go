@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/static"
+)
+
+var ErrAnalysisTimeout = errors.New("analysis timed out")
+
+// ComputeFallbackCallgraph computes the call graph like ComputeCallgraph, but does not come back empty-handed:
+// if the analysis fails, or does not finish within the timeout (if not 0), it falls back to the static call graph,
+// with only the statically resolved calls, and returns it together with the analysis error.
+// The abandoned analysis is recorded in data.Fallbacks, and keeps running in the background if it timed out.
+func (mode AnalysisMode) ComputeFallbackCallgraph(data *ProgramAnalysis, timeout time.Duration) (cg *callgraph.Graph, failure error) {
+	cg, failure = mode.computeWithTimeout(data, timeout)
+	if failure == nil {
+		return cg, nil
+	}
+	if mode == StaticAnalysis {
+		return nil, failure
+	}
+	data.Fallbacks = append(data.Fallbacks, Fallback{Mode: mode, Reflection: mode == PointerAnalysis && data.Pointer.Reflection, Err: failure})
+	return static.CallGraph(data.Prog), failure
+}
+
+// computeWithTimeout computes the call graph like ComputeCallgraph, but gives up after the timeout, if not 0.
+// A timed out analysis is abandoned, but keeps running in the background.
+func (mode AnalysisMode) computeWithTimeout(data *ProgramAnalysis, timeout time.Duration) (*callgraph.Graph, error) {
+	if timeout == 0 {
+		return mode.ComputeCallgraph(data)
+	}
+	type result struct {
+		cg  *callgraph.Graph
+		err error
+	}
+	done := make(chan result, 1)
+	// the analysis may add results to the data, work on a copy to not race with an abandoned analysis.
+	work := *data
+	go func() {
+		cg, err := mode.ComputeCallgraph(&work)
+		done <- result{cg, err}
+	}()
+	select {
+	case res := <-done:
+		if res.err == nil {
+			*data = work
+		}
+		return res.cg, res.err
+	case <-time.After(timeout):
+		return nil, &AnalysisError{Mode: mode, Err: fmt.Errorf("%w after %s", ErrAnalysisTimeout, timeout)}
+	}
+}
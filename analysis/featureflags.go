@@ -0,0 +1,175 @@
+package analysis
+
+import (
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// FlagGate is the code gated by a feature flag: the functions that are only reachable from the roots
+// through calls that are executed depending on the result of the accessor of the flag.
+type FlagGate struct {
+	// Flag is the fully qualified name of the accessor function of the flag, e.g. "example.com/flags.NewCheckout"
+	Flag string `json:"flag"`
+	// Calls are the call sites in the branches of conditions on the result of the accessor.
+	Calls []ssa.CallInstruction `json:"-"`
+	// Funcs are the functions only reachable through the guarded calls.
+	Funcs []*ssa.Function `json:"-"`
+	// Gated are the names of the gated functions, sorted.
+	Gated []string `json:"gated"`
+}
+
+// guardBlocks returns the blocks that are only entered depending on the value, directly,
+// negated, compared, or extracted from the results of the call.
+func guardBlocks(v ssa.Value, out []*ssa.BasicBlock) []*ssa.BasicBlock {
+	refs := v.Referrers()
+	if refs == nil {
+		return out
+	}
+	for _, instr := range *refs {
+		switch instr := instr.(type) {
+		case *ssa.If:
+			for _, succ := range instr.Block().Succs {
+				// the successor is the merge point if it can be entered otherwise
+				if len(succ.Preds) == 1 {
+					out = append(out, succ)
+				}
+			}
+		case *ssa.UnOp:
+			if instr.Op == token.NOT {
+				out = guardBlocks(instr, out)
+			}
+		case *ssa.BinOp:
+			if instr.Op == token.EQL || instr.Op == token.NEQ {
+				out = guardBlocks(instr, out)
+			}
+		case *ssa.Extract:
+			out = guardBlocks(instr, out)
+		}
+	}
+	return out
+}
+
+// guardedCalls returns the call sites, per accessor, in the blocks dominated by the branches of conditions
+// on the results of calls to the accessor.
+func (data *ProgramAnalysis) guardedCalls(accessors map[*ssa.Function]bool) map[*ssa.Function][]ssa.CallInstruction {
+	out := make(map[*ssa.Function][]ssa.CallInstruction)
+	for fn := range ssautil.AllFunctions(data.Prog) {
+		guards := make(map[*ssa.Function][]*ssa.BasicBlock)
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				call, ok := instr.(*ssa.Call)
+				if !ok {
+					continue
+				}
+				if callee := call.Call.StaticCallee(); callee != nil && accessors[callee] {
+					guards[callee] = guardBlocks(call, guards[callee])
+				}
+			}
+		}
+		for accessor, blocks := range guards {
+			for _, b := range fn.Blocks {
+				if !dominatedByAny(b, blocks) {
+					continue
+				}
+				for _, instr := range b.Instrs {
+					if site, ok := instr.(ssa.CallInstruction); ok {
+						out[accessor] = append(out[accessor], site)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func dominatedByAny(b *ssa.BasicBlock, guards []*ssa.BasicBlock) bool {
+	for _, g := range guards {
+		if g.Dominates(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// reachableWithout returns the functions reachable from the roots in the call graph, without following the skipped calls.
+func reachableWithout(cg *callgraph.Graph, roots []*ssa.Function, skip map[ssa.CallInstruction]bool) map[*ssa.Function]bool {
+	reachable := make(map[*ssa.Function]bool)
+	var visit func(n *callgraph.Node)
+	visit = func(n *callgraph.Node) {
+		if reachable[n.Func] {
+			return
+		}
+		reachable[n.Func] = true
+		for _, e := range n.Out {
+			if e.Site == nil || !skip[e.Site] {
+				visit(e.Callee)
+			}
+		}
+	}
+	for _, fn := range roots {
+		if n, ok := cg.Nodes[fn]; ok {
+			visit(n)
+		}
+	}
+	return reachable
+}
+
+// FlagGates finds, for each feature-flag accessor, the calls guarded by conditions on its result,
+// and the functions that the call graph reaches from the roots only through those calls.
+// Accessors are fully qualified function names, e.g. "(*example.com/flags.Client).Enabled".
+func (data *ProgramAnalysis) FlagGates(cg *callgraph.Graph, accessors []string) ([]FlagGate, error) {
+	fns, err := data.LookupFuncs(accessors)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := data.rootFuncs()
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[*ssa.Function]bool)
+	for _, fn := range fns {
+		set[fn] = true
+	}
+	guarded := data.guardedCalls(set)
+	all := reachableWithout(cg, roots, nil)
+
+	var out []FlagGate
+	for i, fn := range fns {
+		gate := FlagGate{Flag: accessors[i], Calls: guarded[fn]}
+		skip := make(map[ssa.CallInstruction]bool)
+		for _, site := range gate.Calls {
+			skip[site] = true
+		}
+		without := reachableWithout(cg, roots, skip)
+		for f := range all {
+			if !without[f] {
+				gate.Funcs = append(gate.Funcs, f)
+			}
+		}
+		sort.Slice(gate.Funcs, func(i, j int) bool { return gate.Funcs[i].String() < gate.Funcs[j].String() })
+		for _, f := range gate.Funcs {
+			gate.Gated = append(gate.Gated, f.String())
+		}
+		out = append(out, gate)
+	}
+	return out, nil
+}
+
+// FlagLabels returns the flags gating each function, and the flags guarding each call site.
+func FlagLabels(gates []FlagGate) (funcs map[*ssa.Function][]string, calls map[ssa.CallInstruction][]string) {
+	funcs = make(map[*ssa.Function][]string)
+	calls = make(map[ssa.CallInstruction][]string)
+	for _, g := range gates {
+		for _, f := range g.Funcs {
+			funcs[f] = append(funcs[f], g.Flag)
+		}
+		for _, site := range g.Calls {
+			calls[site] = append(calls[site], g.Flag)
+		}
+	}
+	return funcs, calls
+}
@@ -0,0 +1,45 @@
+package analysis
+
+import (
+	"go/types"
+)
+
+// FindImplementations maps the interfaces declared in the analyzed (initial) packages to the named types of all packages,
+// or pointers to them, that implement them. The value type is listed if it implements the interface, the pointer type
+// if only the pointer does. Empty interfaces are left out, as every type implements them.
+func FindImplementations(data *ProgramAnalysis) map[*types.Named][]types.Type {
+	initial := data.initialSet()
+	var ifaces, concrete []*types.Named
+	for _, p := range data.Pkgs {
+		scope := p.Pkg.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			named, ok := obj.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			if iface, ok := named.Underlying().(*types.Interface); ok {
+				if initial[p] && !iface.Empty() {
+					ifaces = append(ifaces, named)
+				}
+			} else {
+				concrete = append(concrete, named)
+			}
+		}
+	}
+	impls := make(map[*types.Named][]types.Type)
+	for _, named := range ifaces {
+		iface := named.Underlying().(*types.Interface)
+		for _, t := range concrete {
+			if types.Implements(t, iface) {
+				impls[named] = append(impls[named], t)
+			} else if ptr := types.NewPointer(t); types.Implements(ptr, iface) {
+				impls[named] = append(impls[named], ptr)
+			}
+		}
+	}
+	return impls
+}
@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// isInit tells if the function is a package initializer: the synthetic init function of a package,
+// or one of the init functions declared in it, named init#1, init#2, etc. in SSA.
+func isInit(fn *ssa.Function) bool {
+	if fn == nil || fn.Pkg == nil || fn.Parent() != nil {
+		return false
+	}
+	return fn.Name() == "init" || strings.HasPrefix(fn.Name(), "init#")
+}
+
+// pruneInits removes the package initializers from the call graph, and the functions only reachable from them.
+// Functions that are reachable from any other function without callers, or from the root, are kept.
+func pruneInits(cg *callgraph.Graph) {
+	fromInit := make(map[*callgraph.Node]bool)
+	fromOther := make(map[*callgraph.Node]bool)
+	var visit func(n *callgraph.Node, seen map[*callgraph.Node]bool, skipInits bool)
+	visit = func(n *callgraph.Node, seen map[*callgraph.Node]bool, skipInits bool) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		for _, e := range n.Out {
+			// other functions reach the initializers only through the root, that is not an init dependency
+			if skipInits && isInit(e.Callee.Func) {
+				continue
+			}
+			visit(e.Callee, seen, skipInits)
+		}
+	}
+	for _, n := range cg.Nodes {
+		if isInit(n.Func) {
+			visit(n, fromInit, false)
+		} else if n == cg.Root || len(n.In) == 0 {
+			visit(n, fromOther, true)
+		}
+	}
+	var remove []*callgraph.Node
+	for _, n := range cg.Nodes {
+		if fromInit[n] && !fromOther[n] && n != cg.Root {
+			remove = append(remove, n)
+		}
+	}
+	for _, n := range remove {
+		cg.DeleteNode(n)
+	}
+}
@@ -0,0 +1,143 @@
+package analysis
+
+import (
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// EscapingPanic is a function with a panic that can escape to a root of the program.
+type EscapingPanic struct {
+	// Fn is the panicking function.
+	Fn   *ssa.Function `json:"-"`
+	Func string        `json:"func"`
+	File string        `json:"file"`
+	Line int           `json:"line"`
+}
+
+// panics tells if the function calls panic.
+func panics(fn *ssa.Function) bool {
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if _, ok := instr.(*ssa.Panic); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// callsRecover tells if the function calls recover.
+func callsRecover(fn *ssa.Function) bool {
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if call, ok := instr.(*ssa.Call); ok {
+				if b, ok := call.Call.Value.(*ssa.Builtin); ok && b.Name() == "recover" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// defersRecover tells if the function defers a function that calls recover,
+// which stops the panics of the function, and of the functions it calls.
+func defersRecover(fn *ssa.Function) bool {
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if d, ok := instr.(*ssa.Defer); ok {
+				if callee := d.Call.StaticCallee(); callee != nil && callsRecover(callee) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// PanicFuncs returns the functions of the call graph that call panic, and those that call recover, to mark them in the graph.
+func PanicFuncs(cg *callgraph.Graph) (panicking map[*ssa.Function]bool, recovering map[*ssa.Function]bool) {
+	panicking = make(map[*ssa.Function]bool)
+	recovering = make(map[*ssa.Function]bool)
+	for fn := range cg.Nodes {
+		if fn == nil {
+			continue
+		}
+		if panics(fn) {
+			panicking[fn] = true
+		}
+		if callsRecover(fn) {
+			recovering[fn] = true
+		}
+	}
+	return panicking, recovering
+}
+
+// FindEscapingPanics lists the functions of the analyzed (initial) packages that call panic,
+// and that the call graph reaches from a root of the program along a path without a function that defers a recover.
+// Goroutines do not inherit the recovers of the function that starts them.
+// Runtime panics, e.g. of nil dereferences, are not considered.
+func FindEscapingPanics(data *ProgramAnalysis, cg *callgraph.Graph) ([]EscapingPanic, error) {
+	roots, err := data.rootFuncs()
+	if err != nil {
+		return nil, err
+	}
+	// a function is visited at most twice: once along a path with a recover, and once along a path without
+	type visit struct {
+		fn        *ssa.Function
+		recovered bool
+	}
+	visited := make(map[visit]bool)
+	escaping := make(map[*ssa.Function]bool)
+	var walk func(n *callgraph.Node, recovered bool)
+	walk = func(n *callgraph.Node, recovered bool) {
+		if visited[visit{n.Func, recovered}] {
+			return
+		}
+		visited[visit{n.Func, recovered}] = true
+		recovered = recovered || defersRecover(n.Func)
+		if !recovered && panics(n.Func) {
+			escaping[n.Func] = true
+		}
+		for _, e := range n.Out {
+			_, spawned := e.Site.(*ssa.Go)
+			walk(e.Callee, recovered && !spawned)
+		}
+	}
+	for _, fn := range roots {
+		if n, ok := cg.Nodes[fn]; ok {
+			walk(n, false)
+		}
+	}
+
+	initial := data.initialSet()
+	var out []EscapingPanic
+	for fn := range escaping {
+		if !initial[fn.Pkg] {
+			continue
+		}
+		pos := data.Prog.Fset.Position(fn.Pos())
+		out = append(out, EscapingPanic{Fn: fn, Func: fn.String(), File: pos.Filename, Line: pos.Line})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		if out[i].Line != out[j].Line {
+			return out[i].Line < out[j].Line
+		}
+		return out[i].Func < out[j].Func
+	})
+	return out, nil
+}
+
+// EscapingPanicFuncs returns the set of functions of the escaping panics, to mark them in the graph.
+func EscapingPanicFuncs(escaping []EscapingPanic) map[*ssa.Function]bool {
+	out := make(map[*ssa.Function]bool, len(escaping))
+	for _, p := range escaping {
+		out[p.Fn] = true
+	}
+	return out
+}
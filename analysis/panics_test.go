@@ -0,0 +1,91 @@
+package analysis
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/callgraph/static"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// testProgram builds the main package of the source, without imports, as the program to analyze.
+func testProgram(t *testing.T, src string) *ProgramAnalysis {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", "package main\n"+src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := types.NewPackage("example.com/app", "main")
+	ssaPkg, _, err := ssautil.BuildPackage(&types.Config{}, fset, pkg, []*ast.File{f}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ProgramAnalysis{
+		Prog:    ssaPkg.Prog,
+		Pkgs:    []*ssa.Package{ssaPkg},
+		Mains:   []*ssa.Package{ssaPkg},
+		Initial: []*ssa.Package{ssaPkg},
+		NoInits: true,
+	}
+}
+
+func TestFindEscapingPanics(t *testing.T) {
+	cases := []struct {
+		name  string
+		src   string
+		funcs []string
+	}{
+		{"direct", `func main() { panic("boom") }`, []string{"example.com/app.main"}},
+		{"callee", `
+func main() { f() }
+func f() { panic("boom") }`, []string{"example.com/app.f"}},
+		{"recovered", `
+func main() {
+	defer func() { recover() }()
+	f()
+}
+func f() { panic("boom") }`, nil},
+		{"recovered on one path", `
+func main() { g(); h() }
+func g() {
+	defer func() { recover() }()
+	f()
+}
+func h() { f() }
+func f() { panic("boom") }`, []string{"example.com/app.f"}},
+		{"goroutine", `
+func main() {
+	defer func() { recover() }()
+	go f()
+}
+func f() { panic("boom") }`, []string{"example.com/app.f"}},
+		{"unreachable", `
+func main() {}
+func f() { panic("boom") }`, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data := testProgram(t, c.src)
+			escaping, err := FindEscapingPanics(data, static.CallGraph(data.Prog))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range escaping {
+				got = append(got, p.Func)
+				if p.File != "main.go" || p.Line == 0 {
+					t.Errorf("got position %s:%d of %s, expected a line of main.go", p.File, p.Line, p.Func)
+				}
+			}
+			if !reflect.DeepEqual(got, c.funcs) {
+				t.Errorf("got escaping panics in %v, expected %v", got, c.funcs)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"go/constant"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// PluginLink is a function of a plugin, looked up by name with plugin.Lookup by a function of the host program.
+type PluginLink struct {
+	Caller *ssa.Function
+	Callee *ssa.Function
+	Symbol string
+}
+
+// pluginLookups maps the functions of the analyzed (initial) packages to the constant symbol names
+// they look up in plugins.
+func (data *ProgramAnalysis) pluginLookups() map[*ssa.Function][]string {
+	initial := data.initialSet()
+	out := make(map[*ssa.Function][]string)
+	for fn := range ssautil.AllFunctions(data.Prog) {
+		if !initial[fn.Pkg] {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				site, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+				common := site.Common()
+				if pkg, name, ok := calleeID(common); !ok || pkg != "plugin" || name != "Lookup" {
+					continue
+				}
+				for _, a := range common.Args {
+					if c, ok := a.(*ssa.Const); ok && c.Value != nil && c.Value.Kind() == constant.String {
+						out[fn] = append(out[fn], constant.StringVal(c.Value))
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// PluginLinks matches the plugin.Lookup calls of the host program, with constant symbol names,
+// to the functions of that name in the analyzed (initial) packages of the plugins.
+// Looked up variables are not linked.
+func (data *ProgramAnalysis) PluginLinks(plugins *ProgramAnalysis) []PluginLink {
+	var out []PluginLink
+	for caller, symbols := range data.pluginLookups() {
+		for _, symbol := range symbols {
+			for _, p := range plugins.Initial {
+				if p == nil {
+					continue
+				}
+				if fn := p.Func(symbol); fn != nil {
+					out = append(out, PluginLink{Caller: caller, Callee: fn, Symbol: symbol})
+				}
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if a, b := out[i].Caller.String(), out[j].Caller.String(); a != b {
+			return a < b
+		}
+		return out[i].Callee.String() < out[j].Callee.String()
+	})
+	return out
+}
@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"strings"
+
+	"golang.org/x/tools/go/pointer"
+)
+
+// PointerOptions tunes the pointer analysis. The zero value is the default configuration.
+type PointerOptions struct {
+	// Reflection enables the analysis of reflection calls: more precise, but much more expensive.
+	Reflection bool
+	// Log receives the (very verbose) log of the analysis, if not nil.
+	Log io.Writer
+	// Queries lists package-level variables, formatted as "import/path.Name", to report the points-to set of.
+	Queries []string
+	// QueryOut receives the points-to sets of the queries, and the warnings of the analysis, if not nil.
+	QueryOut io.Writer
+}
+
+func (opts *PointerOptions) config(data *ProgramAnalysis) (*pointer.Config, error) {
+	conf := &pointer.Config{
+		Mains:          data.Mains,
+		Reflection:     opts.Reflection,
+		BuildCallGraph: true,
+		Log:            opts.Log,
+	}
+	for _, q := range opts.Queries {
+		dot := strings.LastIndex(q, ".")
+		if dot < 0 {
+			return nil, fmt.Errorf("pointer query %q is not formatted as import/path.Name", q)
+		}
+		pkg := data.Prog.ImportedPackage(q[:dot])
+		if pkg == nil {
+			return nil, fmt.Errorf("pointer query %q: package not found", q)
+		}
+		v := pkg.Var(q[dot+1:])
+		if v == nil {
+			return nil, fmt.Errorf("pointer query %q: package-level variable not found", q)
+		}
+		// globals are pointers to their variables, query what the variable itself points to.
+		if !pointer.CanPoint(v.Type().(*types.Pointer).Elem()) {
+			return nil, fmt.Errorf("pointer query %q: variable type cannot point to anything", q)
+		}
+		conf.AddIndirectQuery(v)
+	}
+	return conf, nil
+}
+
+func (opts *PointerOptions) report(data *ProgramAnalysis, result *pointer.Result) error {
+	if opts.QueryOut == nil {
+		return nil
+	}
+	for _, q := range opts.Queries {
+		dot := strings.LastIndex(q, ".")
+		v := data.Prog.ImportedPackage(q[:dot]).Var(q[dot+1:])
+		if _, err := fmt.Fprintf(opts.QueryOut, "query %s: %s\n", q, result.IndirectQueries[v].PointsTo()); err != nil {
+			return err
+		}
+	}
+	for _, w := range result.Warnings {
+		if _, err := fmt.Fprintf(opts.QueryOut, "warning %s: %s\n", data.Prog.Fset.Position(w.Pos), w.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// PolymorphicCall is a dynamic call site, with the number of callees the analysis resolved it to.
+type PolymorphicCall struct {
+	Caller string `json:"caller"`
+	Pos    string `json:"pos"`
+	// Kind is "invoke" for interface method calls, and "dynamic" for calls of function values.
+	Kind string `json:"kind"`
+	// Target is the interface method, or the type of the function value.
+	Target  string `json:"target"`
+	Callees int    `json:"callees"`
+}
+
+// Polymorphism summarizes how many callees the dynamic call sites of the analyzed packages resolve to.
+// Sites with many callees are a risk to the precision of the graph, and may point to overly generic designs.
+type Polymorphism struct {
+	// Histogram maps numbers of callees to the number of dynamic call sites with that many callees.
+	Histogram map[int]int `json:"histogram"`
+	// Top are the call sites with the most callees, most first.
+	Top []PolymorphicCall `json:"top"`
+}
+
+// FindPolymorphism counts the callees of the dynamic call sites in the functions of the analyzed (initial) packages,
+// in the call graph, and lists the top call sites with the most callees.
+func FindPolymorphism(data *ProgramAnalysis, cg *callgraph.Graph, top int) *Polymorphism {
+	callees := make(map[ssa.CallInstruction]map[*ssa.Function]bool)
+	for _, n := range cg.Nodes {
+		for _, e := range n.Out {
+			if e.Site == nil {
+				continue
+			}
+			if callees[e.Site] == nil {
+				callees[e.Site] = make(map[*ssa.Function]bool)
+			}
+			callees[e.Site][e.Callee.Func] = true
+		}
+	}
+
+	initial := data.initialSet()
+	out := &Polymorphism{Histogram: make(map[int]int)}
+	for fn := range ssautil.AllFunctions(data.Prog) {
+		if !initial[fn.Pkg] {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				site, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+				common := site.Common()
+				if _, ok := common.Value.(*ssa.Builtin); ok || common.StaticCallee() != nil {
+					continue
+				}
+				call := PolymorphicCall{
+					Caller:  fn.String(),
+					Pos:     data.Prog.Fset.Position(site.Pos()).String(),
+					Callees: len(callees[site]),
+				}
+				if common.IsInvoke() {
+					call.Kind = "invoke"
+					call.Target = common.Method.FullName()
+				} else {
+					call.Kind = "dynamic"
+					call.Target = common.Value.Type().String()
+				}
+				out.Histogram[call.Callees]++
+				out.Top = append(out.Top, call)
+			}
+		}
+	}
+	sort.Slice(out.Top, func(i, j int) bool {
+		a, b := &out.Top[i], &out.Top[j]
+		if a.Callees != b.Callees {
+			return a.Callees > b.Callees
+		}
+		if a.Caller != b.Caller {
+			return a.Caller < b.Caller
+		}
+		return a.Pos < b.Pos
+	})
+	if len(out.Top) > top {
+		out.Top = out.Top[:top]
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// reflectiveMethods are the methods of reflect.Value, and reflect.Type, that call, or look up to call,
+// methods by name at run time: their callees are invisible to all analysis modes.
+var reflectiveMethods = map[string]bool{"Call": true, "CallSlice": true, "MethodByName": true}
+
+// callsReflectively tells if the function calls reflect.Value.Call, CallSlice or MethodByName, or reflect.Type.MethodByName.
+func callsReflectively(fn *ssa.Function) bool {
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			site, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			common := site.Common()
+			if common.IsInvoke() {
+				if reflectiveMethods[common.Method.Name()] && types.TypeString(common.Value.Type(), nil) == "reflect.Type" {
+					return true
+				}
+				continue
+			}
+			callee := common.StaticCallee()
+			if callee != nil && callee.Pkg != nil && callee.Pkg.Pkg.Path() == "reflect" &&
+				callee.Signature.Recv() != nil && reflectiveMethods[callee.Name()] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReflectiveFuncs returns the functions of the call graph that call methods through reflection, to mark them in the graph.
+func ReflectiveFuncs(cg *callgraph.Graph) map[*ssa.Function]bool {
+	out := make(map[*ssa.Function]bool)
+	for fn := range cg.Nodes {
+		if fn != nil && callsReflectively(fn) {
+			out[fn] = true
+		}
+	}
+	return out
+}
+
+// reflectionWarning returns a warning about the functions of the initial packages that call methods through reflection,
+// or an empty string if there are none.
+func reflectionWarning(prog *ssa.Program, initial []*ssa.Package) string {
+	set := make(map[*ssa.Package]bool, len(initial))
+	for _, p := range initial {
+		set[p] = true
+	}
+	count := 0
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Pkg != nil && set[fn.Pkg] && callsReflectively(fn) {
+			count++
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d functions call methods through reflection (reflect.Value.Call or MethodByName), "+
+		"the call graph is missing their callees: they are marked with the unsound class", count)
+}
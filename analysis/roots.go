@@ -0,0 +1,133 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// funcsByName indexes all functions of the program by their fully qualified name, e.g. "(*net/http.Server).Serve".
+func funcsByName(prog *ssa.Program) map[string]*ssa.Function {
+	out := make(map[string]*ssa.Function)
+	for fn := range ssautil.AllFunctions(prog) {
+		out[fn.String()] = fn
+	}
+	return out
+}
+
+// LookupFuncs resolves fully qualified function names, e.g. "github.com/example/pkg.HandleRequest",
+// or "(*github.com/example/pkg.Server).Serve" for methods.
+func (data *ProgramAnalysis) LookupFuncs(names []string) ([]*ssa.Function, error) {
+	byName := funcsByName(data.Prog)
+	var out []*ssa.Function
+	for _, name := range names {
+		fn, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("function not found: %q", name)
+		}
+		out = append(out, fn)
+	}
+	return out, nil
+}
+
+// rootFuncs returns the entry points of the program: the custom roots if any. Otherwise the init and main
+// functions of the main packages, or the exported API of the analyzed packages if the program is a library,
+// and the functions registered with known frameworks, or with the runtime, and the functions run by the go test tool.
+func (data *ProgramAnalysis) rootFuncs() ([]*ssa.Function, error) {
+	if len(data.Roots) > 0 {
+		return data.LookupFuncs(data.Roots)
+	}
+	var roots []*ssa.Function
+	if len(data.Mains) == 0 {
+		roots = data.libraryRoots()
+	}
+	for _, m := range data.Mains {
+		if !data.NoInits {
+			roots = append(roots, m.Func("init"))
+		}
+		roots = append(roots, m.Func("main"))
+	}
+	for _, ep := range data.Entrypoints {
+		roots = append(roots, ep.Func)
+	}
+	for _, cb := range data.Callbacks {
+		roots = append(roots, cb.Func)
+	}
+	for _, t := range data.Tests {
+		roots = append(roots, t.Func)
+	}
+	return roots, nil
+}
+
+// libraryRoots returns the init functions (unless excluded), exported functions, and exported methods of exported types,
+// of the initial packages.
+func (data *ProgramAnalysis) libraryRoots() []*ssa.Function {
+	var roots []*ssa.Function
+	for _, p := range data.Initial {
+		if p == nil {
+			continue
+		}
+		names := make([]string, 0, len(p.Members))
+		for name := range p.Members {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			switch m := p.Members[name].(type) {
+			case *ssa.Function:
+				if (name == "init" && !data.NoInits) || (m.Object() != nil && m.Object().Exported()) {
+					roots = append(roots, m)
+				}
+			case *ssa.Type:
+				if !m.Object().Exported() {
+					continue
+				}
+				// the pointer method set includes the value methods
+				mset := data.Prog.MethodSets.MethodSet(types.NewPointer(m.Type()))
+				for i := 0; i < mset.Len(); i++ {
+					sel := mset.At(i)
+					if !sel.Obj().Exported() {
+						continue
+					}
+					if fn := data.Prog.MethodValue(sel); fn != nil {
+						roots = append(roots, fn)
+					}
+				}
+			}
+		}
+	}
+	return roots
+}
+
+// pruneUnreachable removes the nodes of the call graph that cannot be reached from the roots.
+func pruneUnreachable(cg *callgraph.Graph, roots []*ssa.Function) {
+	reachable := make(map[*callgraph.Node]bool)
+	var visit func(n *callgraph.Node)
+	visit = func(n *callgraph.Node) {
+		if reachable[n] {
+			return
+		}
+		reachable[n] = true
+		for _, e := range n.Out {
+			visit(e.Callee)
+		}
+	}
+	for _, fn := range roots {
+		if n, ok := cg.Nodes[fn]; ok {
+			visit(n)
+		}
+	}
+	var unreachable []*callgraph.Node
+	for _, n := range cg.Nodes {
+		if !reachable[n] && n != cg.Root {
+			unreachable = append(unreachable, n)
+		}
+	}
+	for _, n := range unreachable {
+		cg.DeleteNode(n)
+	}
+}
@@ -0,0 +1,36 @@
+package analysis
+
+import (
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/callgraph/rta"
+)
+
+type ReachableFunc struct {
+	Name string `json:"name"`
+	// AddrTaken is true if the function is used as a value, and may be called dynamically.
+	AddrTaken bool `json:"addr_taken"`
+}
+
+// Reachability lists the runtime types and reachable functions found by rapid type analysis,
+// useful to find dead code.
+type Reachability struct {
+	RuntimeTypes []string        `json:"runtime_types"`
+	Functions    []ReachableFunc `json:"functions"`
+}
+
+func newReachability(res *rta.Result) *Reachability {
+	out := &Reachability{}
+	for _, t := range res.RuntimeTypes.Keys() {
+		out.RuntimeTypes = append(out.RuntimeTypes, types.TypeString(t, nil))
+	}
+	sort.Strings(out.RuntimeTypes)
+	for fn, info := range res.Reachable {
+		out.Functions = append(out.Functions, ReachableFunc{Name: fn.String(), AddrTaken: info.AddrTaken})
+	}
+	sort.Slice(out.Functions, func(i, j int) bool {
+		return out.Functions[i].Name < out.Functions[j].Name
+	})
+	return out
+}
@@ -0,0 +1,66 @@
+package analysis
+
+import (
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// UnresolvedCall is a dynamic call site, without any callees in a static call graph.
+type UnresolvedCall struct {
+	Caller string `json:"caller"`
+	Pos    string `json:"pos"`
+	// Kind is "invoke" for interface method calls, and "dynamic" for calls of function values.
+	Kind string `json:"kind"`
+	// Target is the interface method, or the type of the function value.
+	Target string `json:"target"`
+}
+
+// UnresolvedCalls lists the dynamic call sites in the functions of the analyzed (initial) packages.
+// A static call graph has no edges for these, so they show where the graph is incomplete.
+func UnresolvedCalls(data *ProgramAnalysis) []UnresolvedCall {
+	initial := make(map[*ssa.Package]bool)
+	for _, p := range data.Initial {
+		if p != nil {
+			initial[p] = true
+		}
+	}
+	var out []UnresolvedCall
+	for fn := range ssautil.AllFunctions(data.Prog) {
+		if !initial[fn.Pkg] {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				site, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+				common := site.Common()
+				if _, ok := common.Value.(*ssa.Builtin); ok || common.StaticCallee() != nil {
+					continue
+				}
+				call := UnresolvedCall{
+					Caller: fn.String(),
+					Pos:    data.Prog.Fset.Position(site.Pos()).String(),
+				}
+				if common.IsInvoke() {
+					call.Kind = "invoke"
+					call.Target = common.Method.FullName()
+				} else {
+					call.Kind = "dynamic"
+					call.Target = common.Value.Type().String()
+				}
+				out = append(out, call)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Caller != out[j].Caller {
+			return out[i].Caller < out[j].Caller
+		}
+		return out[i].Pos < out[j].Pos
+	})
+	return out
+}
@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Spawner is a function that starts goroutines.
+type Spawner struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Spawns is the number of go statements in the function.
+	Spawns int `json:"spawns"`
+	// Reachable tells if the call graph reaches the function from the roots of the program.
+	Reachable bool `json:"reachable"`
+}
+
+// goStatements counts the go statements of the function, closures are counted separately.
+func goStatements(fn *ssa.Function) int {
+	count := 0
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if _, ok := instr.(*ssa.Go); ok {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// SpawnCounts maps the functions of the call graph that start goroutines to their number of go statements,
+// to mark them in the graph.
+func SpawnCounts(cg *callgraph.Graph) map[*ssa.Function]int {
+	out := make(map[*ssa.Function]int)
+	for fn := range cg.Nodes {
+		if fn == nil {
+			continue
+		}
+		if spawns := goStatements(fn); spawns > 0 {
+			out[fn] = spawns
+		}
+	}
+	return out
+}
+
+// FindSpawners lists the functions, including closures, of the analyzed (initial) packages that start goroutines,
+// with the number of go statements in them, and whether they are reachable from the roots of the program.
+func FindSpawners(data *ProgramAnalysis, cg *callgraph.Graph) ([]Spawner, error) {
+	reachable, err := data.reachableFuncs(cg)
+	if err != nil {
+		return nil, err
+	}
+	initial := data.initialSet()
+	var out []Spawner
+	for fn := range ssautil.AllFunctions(data.Prog) {
+		if !initial[fn.Pkg] {
+			continue
+		}
+		spawns := goStatements(fn)
+		if spawns == 0 {
+			continue
+		}
+		pos := data.Prog.Fset.Position(fn.Pos())
+		out = append(out, Spawner{Func: fn.String(), File: pos.Filename, Line: pos.Line, Spawns: spawns, Reachable: reachable[fn]})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		if out[i].Line != out[j].Line {
+			return out[i].Line < out[j].Line
+		}
+		return out[i].Func < out[j].Func
+	})
+	return out, nil
+}
+
+// WriteSpawnersText writes each spawner on a line, prefixed with its position, e.g.
+// "server.go:12: (*pkg.Server).Serve: 2 goroutines", marked if it is unreachable.
+func WriteSpawnersText(w io.Writer, spawners []Spawner) error {
+	for _, s := range spawners {
+		unreachable := ""
+		if !s.Reachable {
+			unreachable = " (unreachable)"
+		}
+		if _, err := fmt.Fprintf(w, "%s:%d: %s: %d goroutines%s\n", s.File, s.Line, s.Func, s.Spawns, unreachable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/protolambda/gocyto/graph"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// SplitCluster is a group of closely connected functions within a package, a candidate for a package of its own.
+type SplitCluster struct {
+	Functions []string `json:"functions"`
+	// InternalEdges counts the calls between functions of the cluster.
+	InternalEdges int `json:"internal_edges"`
+	// CrossEdges counts the calls between functions of the cluster and functions of other clusters in the package.
+	CrossEdges int `json:"cross_edges"`
+}
+
+type SplitSuggestion struct {
+	Package  string         `json:"package"`
+	Clusters []SplitCluster `json:"clusters"`
+	// Isolated functions do not call, and are not called by, other functions of the package.
+	Isolated []string `json:"isolated"`
+}
+
+// topLevel returns the declared function that contains fn, so closures are clustered with their parent.
+func topLevel(fn *ssa.Function) *ssa.Function {
+	for fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	return fn
+}
+
+// SuggestSplit clusters the functions of a single package by their calls to each other,
+// to suggest how the package could be split up with the fewest calls crossing the new package boundaries.
+func SuggestSplit(cg *callgraph.Graph, pkgPath string) (*SplitSuggestion, error) {
+	var funcs []*ssa.Function
+	index := make(map[*ssa.Function]int)
+	for fn := range cg.Nodes {
+		if fn == nil || fn.Pkg == nil || fn.Pkg.Pkg.Path() != pkgPath || fn.Synthetic != "" {
+			continue
+		}
+		fn = topLevel(fn)
+		if _, ok := index[fn]; !ok {
+			index[fn] = -1
+			funcs = append(funcs, fn)
+		}
+	}
+	if len(funcs) == 0 {
+		return nil, fmt.Errorf("no functions of package %q in call graph", pkgPath)
+	}
+	names := make([]string, len(funcs))
+	for i, fn := range funcs {
+		names[i] = fn.RelString(fn.Pkg.Pkg)
+	}
+	sort.Sort(byName{funcs, names})
+	for i, fn := range funcs {
+		index[fn] = i
+	}
+
+	g := graph.NewDigraph(len(funcs))
+	err := callgraph.GraphVisitEdges(cg, func(edge *callgraph.Edge) error {
+		if edge.Caller.Func == nil || edge.Callee.Func == nil {
+			return nil
+		}
+		from, ok := index[topLevel(edge.Caller.Func)]
+		if !ok {
+			return nil
+		}
+		to, ok := index[topLevel(edge.Callee.Func)]
+		if !ok {
+			return nil
+		}
+		g.AddEdge(from, to)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	labels := graph.LabelPropagation(g)
+	res := &SplitSuggestion{Package: pkgPath}
+	clusterIndex := make(map[int]int)
+	for _, members := range graph.Groups(labels) {
+		if len(members) == 1 {
+			res.Isolated = append(res.Isolated, names[members[0]])
+			continue
+		}
+		clusterIndex[labels[members[0]]] = len(res.Clusters)
+		c := SplitCluster{}
+		for _, m := range members {
+			c.Functions = append(c.Functions, names[m])
+		}
+		res.Clusters = append(res.Clusters, c)
+	}
+	for from, outs := range g.Out {
+		for _, to := range outs {
+			if from == to {
+				continue
+			}
+			a, aOk := clusterIndex[labels[from]]
+			b, bOk := clusterIndex[labels[to]]
+			if aOk && bOk && a == b {
+				res.Clusters[a].InternalEdges++
+				continue
+			}
+			if aOk {
+				res.Clusters[a].CrossEdges++
+			}
+			if bOk {
+				res.Clusters[b].CrossEdges++
+			}
+		}
+	}
+	sort.SliceStable(res.Clusters, func(i, j int) bool {
+		return len(res.Clusters[i].Functions) > len(res.Clusters[j].Functions)
+	})
+	return res, nil
+}
+
+type byName struct {
+	funcs []*ssa.Function
+	names []string
+}
+
+func (s byName) Len() int           { return len(s.funcs) }
+func (s byName) Less(i, j int) bool { return s.names[i] < s.names[j] }
+func (s byName) Swap(i, j int) {
+	s.funcs[i], s.funcs[j] = s.funcs[j], s.funcs[i]
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+}
+
+func (s *SplitSuggestion) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "split suggestion for package %s: %d clusters\n", s.Package, len(s.Clusters)); err != nil {
+		return err
+	}
+	for i, c := range s.Clusters {
+		if _, err := fmt.Fprintf(w, "\ncluster %d: %d functions, %d internal calls, %d cross-cluster calls\n",
+			i, len(c.Functions), c.InternalEdges, c.CrossEdges); err != nil {
+			return err
+		}
+		for _, f := range c.Functions {
+			if _, err := fmt.Fprintf(w, "  %s\n", f); err != nil {
+				return err
+			}
+		}
+	}
+	if len(s.Isolated) > 0 {
+		if _, err := fmt.Fprintf(w, "\nisolated: %d functions\n", len(s.Isolated)); err != nil {
+			return err
+		}
+		for _, f := range s.Isolated {
+			if _, err := fmt.Fprintf(w, "  %s\n", f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// StdlibFunc is a standard library function, or method, called by the analyzed packages.
+type StdlibFunc struct {
+	Func  string `json:"func"`
+	Count int    `json:"count"`
+	// Sites are the positions of the calls.
+	Sites []string `json:"sites"`
+}
+
+// StdlibPackage is a standard library package, of which functions are called by the analyzed packages.
+type StdlibPackage struct {
+	Path  string       `json:"path"`
+	Count int          `json:"count"`
+	Funcs []StdlibFunc `json:"funcs"`
+}
+
+// StdlibUsage summarizes the direct calls of the analyzed packages to the standard library,
+// e.g. to review the portability of a program to platforms with a partial standard library.
+type StdlibUsage struct {
+	Packages []StdlibPackage `json:"packages"`
+}
+
+// isStdlib tells if the package is part of the standard library: it is not part of a module,
+// and the first element of its path has no dot, unlike the domain of other packages.
+func (data *ProgramAnalysis) isStdlib(path string) bool {
+	if _, ok := data.Modules[path]; ok {
+		return false
+	}
+	first := path
+	if i := strings.Index(path, "/"); i >= 0 {
+		first = path[:i]
+	}
+	return !strings.Contains(first, ".")
+}
+
+// FindStdlibUsage lists the standard library functions that the functions of the analyzed (initial) packages
+// call directly, with the positions of the calls. Calls of interface methods and function values are not included.
+func FindStdlibUsage(data *ProgramAnalysis) *StdlibUsage {
+	initial := data.initialSet()
+	sites := make(map[string]map[string][]string)
+	for fn := range ssautil.AllFunctions(data.Prog) {
+		if !initial[fn.Pkg] || data.isStdlib(fn.Pkg.Pkg.Path()) {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				site, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+				callee := site.Common().StaticCallee()
+				if callee == nil || callee.Pkg == nil || !data.isStdlib(callee.Pkg.Pkg.Path()) {
+					continue
+				}
+				path := callee.Pkg.Pkg.Path()
+				if sites[path] == nil {
+					sites[path] = make(map[string][]string)
+				}
+				name := callee.String()
+				sites[path][name] = append(sites[path][name], data.Prog.Fset.Position(site.Pos()).String())
+			}
+		}
+	}
+
+	usage := &StdlibUsage{}
+	for path, funcs := range sites {
+		p := StdlibPackage{Path: path}
+		for name, positions := range funcs {
+			sort.Strings(positions)
+			p.Funcs = append(p.Funcs, StdlibFunc{Func: name, Count: len(positions), Sites: positions})
+			p.Count += len(positions)
+		}
+		sort.Slice(p.Funcs, func(i, j int) bool {
+			return p.Funcs[i].Func < p.Funcs[j].Func
+		})
+		usage.Packages = append(usage.Packages, p)
+	}
+	sort.Slice(usage.Packages, func(i, j int) bool {
+		return usage.Packages[i].Path < usage.Packages[j].Path
+	})
+	return usage
+}
+
+func (u *StdlibUsage) WriteText(w io.Writer) error {
+	if len(u.Packages) == 0 {
+		_, err := fmt.Fprintln(w, "no standard library calls")
+		return err
+	}
+	for _, p := range u.Packages {
+		if _, err := fmt.Fprintf(w, "%s: %d calls\n", p.Path, p.Count); err != nil {
+			return err
+		}
+		for _, f := range p.Funcs {
+			if _, err := fmt.Fprintf(w, "  %s: %d calls\n", f.Func, f.Count); err != nil {
+				return err
+			}
+			for _, s := range f.Sites {
+				if _, err := fmt.Fprintf(w, "    %s\n", s); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
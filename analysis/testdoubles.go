@@ -0,0 +1,37 @@
+package analysis
+
+import (
+	"go/ast"
+	"path/filepath"
+	"strings"
+)
+
+// mockGenerators are the tools that generate test doubles, as named in the "Code generated by" header.
+var mockGenerators = []string{"MockGen", "mockery", "counterfeiter", "moq"}
+
+// isTestDoubleFile tells if the file declares mocks, fakes or stubs: by its name, e.g. "client_mock.go",
+// or by the generated code header of a mock generator.
+func isTestDoubleFile(filename string, f *ast.File) bool {
+	base := strings.TrimSuffix(filepath.Base(filename), "_test.go")
+	base = strings.TrimSuffix(base, ".go")
+	if strings.HasPrefix(base, "mock_") || strings.HasSuffix(base, "_mock") ||
+		strings.HasPrefix(base, "fake_") || strings.HasSuffix(base, "_fake") {
+		return true
+	}
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, c := range group.List {
+			if !strings.Contains(c.Text, "Code generated by ") {
+				continue
+			}
+			for _, gen := range mockGenerators {
+				if strings.Contains(c.Text, "Code generated by "+gen) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// testFunc is a kind of function that the go test tool runs, recognized by its name prefix and parameter type.
+type testFunc struct {
+	prefix string
+	// param is the name of the type in the testing package of which the function takes a pointer.
+	param string
+	kind  string
+}
+
+var testFuncs = []testFunc{
+	{prefix: "Test", param: "T", kind: "test"},
+	{prefix: "TestMain", param: "M", kind: "test"},
+	{prefix: "Benchmark", param: "B", kind: "benchmark"},
+	{prefix: "Fuzz", param: "F", kind: "fuzz"},
+}
+
+// isTestName tells if the name is the prefix, followed by nothing or a non-lowercase character, like the go tool does.
+func isTestName(name string, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// takesTesting tells if the function has a single parameter, a pointer to the type of the testing package.
+func takesTesting(fn *ssa.Function, typeName string) bool {
+	params := fn.Signature.Params()
+	if params.Len() != 1 || fn.Signature.Results().Len() != 0 {
+		return false
+	}
+	ptr, ok := params.At(0).Type().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "testing" && named.Obj().Name() == typeName
+}
+
+// FindTests finds the functions of test files of the analyzed (initial) packages that the go test tool runs,
+// e.g. tests, benchmarks and fuzz targets. There are none if the packages were not loaded with tests.
+func (data *ProgramAnalysis) FindTests() []Entrypoint {
+	var out []Entrypoint
+	for p := range data.initialSet() {
+		for name, mem := range p.Members {
+			fn, ok := mem.(*ssa.Function)
+			if !ok || !strings.HasSuffix(data.Prog.Fset.Position(fn.Pos()).Filename, "_test.go") {
+				continue
+			}
+			for _, t := range testFuncs {
+				if isTestName(name, t.prefix) && takesTesting(fn, t.param) {
+					out = append(out, Entrypoint{Func: fn, Kind: t.kind, Name: name})
+				}
+			}
+		}
+	}
+	sortEntrypoints(out)
+	return out
+}
+
+// TestKinds maps each test function to its kind, e.g. "test" or "benchmark".
+func (data *ProgramAnalysis) TestKinds() map[*ssa.Function]string {
+	out := make(map[*ssa.Function]string)
+	for _, t := range data.Tests {
+		out[t.Func] = t.Kind
+	}
+	return out
+}
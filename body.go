@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/protolambda/gocyto/analysis"
+	"github.com/protolambda/gocyto/render"
+)
+
+const bodyUsage = `
+Render the control flow of a single function: its SSA basic blocks and instructions,
+and the functions it calls.
+
+Usage:
+
+gocyto body [options...] <function> <package path(s)>
+
+The function is fully qualified, e.g. (*example.com/pkg.Server).Serve
+
+Options:
+
+`
+
+func bodyCmd(args []string) {
+	flags := flag.NewFlagSet("body", flag.ExitOnError)
+	var af analysisFlags
+	flags.BoolVar(&af.tests, "tests", false, "Load the test files of the packages too")
+	flags.Var(&af.queryDirs, "query-dir", "Directory to query from for go packages. Current dir if empty")
+	flags.StringVar(&af.build, "build", "", "Build flags to pass to Go build tool. Separated with spaces")
+	var of outputFlags
+	of.register(flags)
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, bodyUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	aProg, err := analysis.RunAnalysis(af.tests, af.buildFlags(), flags.Args()[1:], af.singleDir())
+	check(err, "could not run program analysis: %v")
+	fns, err := aProg.LookupFuncs([]string{flags.Arg(0)})
+	check(err, "could not find function: %v")
+
+	cytoGraph := render.NewCytoGraph()
+	cytoGraph.LoadFunctionBody(fns[0])
+	if fns[0].Pkg != nil {
+		cytoGraph.Meta.Packages = []string{fns[0].Pkg.Pkg.Path()}
+	}
+	writeGraph(cytoGraph, &of)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/protolambda/gocyto/analysis"
+	"github.com/protolambda/gocyto/render"
+)
+
+// uncachedFlags are the analysis flags that only change how the graph is computed, or where it is cached,
+// not the graph itself.
+var uncachedFlags = map[string]bool{
+	"cache":       true,
+	"cache-dir":   true,
+	"pointer-log": true,
+}
+
+// analysisFlagNames are the names of the flags registered by analysisFlags.
+func analysisFlagNames() map[string]bool {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	var af analysisFlags
+	af.register(fs)
+	names := make(map[string]bool)
+	fs.VisitAll(func(f *flag.Flag) {
+		names[f.Name] = true
+	})
+	return names
+}
+
+// graphCachePath returns the path of the cached graph for the program contents, and the analysis flags.
+func graphCachePath(fs *flag.FlagSet, af *analysisFlags, args []string) (string, error) {
+	h := sha256.New()
+	for _, dir := range af.dirs() {
+		for _, config := range af.buildConfigs() {
+			patterns := append(append([]string(nil), args...), af.pluginPatterns()...)
+			contentHash, err := analysis.ContentHashFor(config, af.tests, af.buildFlags(), patterns, dir)
+			if err != nil {
+				return "", err
+			}
+			_, _ = fmt.Fprintf(h, "content %s\n", contentHash)
+		}
+	}
+	analysisFlags := analysisFlagNames()
+	fs.VisitAll(func(f *flag.Flag) {
+		if analysisFlags[f.Name] && !uncachedFlags[f.Name] {
+			_, _ = fmt.Fprintf(h, "flag %s=%s\n", f.Name, f.Value.String())
+		}
+	})
+	for _, arg := range args {
+		_, _ = fmt.Fprintf(h, "arg %s\n", arg)
+	}
+
+	dir := af.cacheDir
+	if dir == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(userDir, "gocyto")
+	}
+	return filepath.Join(dir, hex.EncodeToString(h.Sum(nil))+".json"), nil
+}
+
+// readGraphCache returns the cached graph, or nil if there is none.
+func readGraphCache(path string) *render.CytoGraph {
+	cg, err := readGraphFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			_, _ = fmt.Fprintf(os.Stderr, "warning: ignoring unreadable graph cache: %v\n", err)
+		}
+		return nil
+	}
+	return cg
+}
+
+// writeGraphCache caches the graph. Failures are not fatal, the graph is just not cached.
+func writeGraphCache(path string, cg *render.CytoGraph) {
+	err := func() error {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		// write to a unique temporary file first, so concurrent runs never read a partial graph,
+		// nor write to the same temporary file
+		f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+		if err != nil {
+			return err
+		}
+		if err := cg.WriteJson(f); err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+			return err
+		}
+		if err := f.Close(); err != nil {
+			_ = os.Remove(f.Name())
+			return err
+		}
+		if err := os.Rename(f.Name(), path); err != nil {
+			_ = os.Remove(f.Name())
+			return err
+		}
+		return nil
+	}()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: could not cache graph: %v\n", err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/protolambda/gocyto/render"
+)
+
+const calleesUsage = `
+Compute the call graph, and render everything transitively reachable from a function,
+with the number of reached functions per package in the "callees" report. Or list them as text.
+
+Usage:
+
+gocyto callees [options...] <function> <package path(s)>
+
+The function is fully qualified, or named with just the package name, e.g. (*pkg.Server).Serve
+
+Options:
+
+`
+
+func calleesCmd(args []string) {
+	flags := flag.NewFlagSet("callees", flag.ExitOnError)
+	var af analysisFlags
+	af.register(flags)
+	var pf profileFlags
+	pf.register(flags)
+	var of outputFlags
+	of.register(flags)
+	depthFlag := flags.Int("depth", 0, "Maximum number of calls away from the function to include callees of. No limit if 0")
+	textFlag := flags.Bool("text", false, "List the reached functions per package as text instead of the graph")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, calleesUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	stopProfiles := pf.start()
+	defer stopProfiles()
+
+	// everything that is reached counts, nothing is filtered out.
+	opts := &render.RenderOptions{
+		IncludeGoRoot:     true,
+		IncludeUnexported: true,
+	}
+	cytoGraph := analyzeGraph(flags, &af, flags.Args()[1:], opts)
+	if !cytoGraph.KeepCallees(flags.Arg(0), *depthFlag) {
+		_, _ = fmt.Fprintf(os.Stderr, "no callees found of %s\n", flags.Arg(0))
+		os.Exit(1)
+	}
+	cytoGraph.SetSortOrder(of.sortOrder())
+	pkgs := cytoGraph.FuncsByPackage()
+	if *textFlag {
+		output(of.out, func(w io.Writer) {
+			check(render.WritePackageFuncsText(w, pkgs), "could not write callees: %v")
+		})
+		return
+	}
+	cytoGraph.AddReport("callees", pkgs)
+	writeGraph(cytoGraph, &of)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/protolambda/gocyto/render"
+)
+
+const callersUsage = `
+Compute the call graph, and render the inverted graph of the callers of a function:
+every way the function can be reached, with arrows from callees to their callers.
+
+Usage:
+
+gocyto callers [options...] <function> <package path(s)>
+
+The function is fully qualified, or named with just the package name, e.g. (*sql.DB).Exec
+
+Options:
+
+`
+
+func callersCmd(args []string) {
+	flags := flag.NewFlagSet("callers", flag.ExitOnError)
+	var af analysisFlags
+	af.register(flags)
+	var pf profileFlags
+	pf.register(flags)
+	var of outputFlags
+	of.register(flags)
+	depthFlag := flags.Int("depth", 0, "Maximum number of calls away from the function to include callers of. No limit if 0")
+	textFlag := flags.Bool("text", false, "List the callers as an indented tree as text instead of the graph")
+	sourceFlag := flags.Bool("source", false, "With -text, include the position and source line of each call")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, callersUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	stopProfiles := pf.start()
+	defer stopProfiles()
+
+	// callers may be reached through any function, nothing is filtered out.
+	opts := &render.RenderOptions{
+		IncludeGoRoot:     true,
+		IncludeUnexported: true,
+	}
+	cytoGraph := analyzeGraph(flags, &af, flags.Args()[1:], opts)
+	if !cytoGraph.KeepCallers(flags.Arg(0), *depthFlag) {
+		_, _ = fmt.Fprintf(os.Stderr, "no callers found of %s\n", flags.Arg(0))
+		os.Exit(1)
+	}
+	if *textFlag {
+		cytoGraph.SetSortOrder(of.sortOrder())
+		var src *render.SourceLines
+		if *sourceFlag {
+			src = render.NewSourceLines()
+		}
+		output(of.out, func(w io.Writer) {
+			check(cytoGraph.WriteCallersText(w, flags.Arg(0), src), "could not write callers: %v")
+		})
+		return
+	}
+	cytoGraph.Invert()
+	writeGraph(cytoGraph, &of)
+}
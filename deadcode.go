@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/protolambda/gocyto/analysis"
+)
+
+const deadcodeUsage = `
+List the functions of the packages that are not reachable from any root of the program,
+with their positions. Use -mode rta for the most precise results.
+
+Usage:
+
+gocyto deadcode [options...] <package path(s)>
+
+Options:
+
+`
+
+func deadcodeCmd(args []string) {
+	flags := flag.NewFlagSet("deadcode", flag.ExitOnError)
+	var af analysisFlags
+	af.register(flags)
+	var pf profileFlags
+	pf.register(flags)
+	jsonFlag := flags.Bool("json", false, "Output the dead functions as JSON instead of text")
+	outFlag := flags.String("out", "", "Output file, if none is specified, output to std out")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, deadcodeUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	stopProfiles := pf.start()
+	defer stopProfiles()
+
+	aProg, _, callGraph, failure := computeCallGraph(&af, af.analysisMode(), nil, af.singleDir(), flags.Args())
+	if failure != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: %v, the static call graph is used, reachable functions may be reported as dead\n", failure)
+	}
+	dead, err := analysis.FindDeadCode(aProg, callGraph)
+	check(err, "could not find dead code: %v")
+	output(*outFlag, func(w io.Writer) {
+		if *jsonFlag {
+			check(json.NewEncoder(w).Encode(dead), "could not write dead code JSON: %v")
+		} else {
+			check(analysis.WriteDeadCodeText(w, dead), "could not write dead code: %v")
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/protolambda/gocyto/render"
+)
+
+const diffUsage = `
+Compare two graph JSON outputs of gocyto, e.g. of two revisions of a program.
+Functions that only changed name are reported as renames.
+
+Usage:
+
+gocyto diff [options...] <old graph.json> <new graph.json>
+
+Options:
+
+`
+
+func readGraphFile(path string) (*render.CytoGraph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return render.ReadJson(f)
+}
+
+func diffCmd(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	jsonFlag := flags.Bool("json", false, "Output the diff as JSON instead of text")
+	outFlag := flags.String("out", "", "Output file, if none is specified, output to std out")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, diffUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	prev, err := readGraphFile(flags.Arg(0))
+	check(err, "could not read old graph: %v")
+	next, err := readGraphFile(flags.Arg(1))
+	check(err, "could not read new graph: %v")
+
+	diff := render.DiffGraphs(prev, next)
+	output(*outFlag, func(w io.Writer) {
+		if *jsonFlag {
+			enc := json.NewEncoder(w)
+			check(enc.Encode(diff), "could not write diff JSON: %v")
+		} else {
+			check(diff.WriteText(w), "could not write diff: %v")
+		}
+	})
+}
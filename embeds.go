@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+
+	"github.com/protolambda/gocyto/analysis"
+	"github.com/protolambda/gocyto/render"
+)
+
+const embedsUsage = `
+Render the type embedding graph instead of the call graph: the structs and interfaces of the packages,
+and the types they embed, transitively, with an edge from each type to each type it embeds.
+
+Usage:
+
+gocyto embeds [options...] <package path(s)>
+
+Options:
+
+`
+
+func embedsCmd(args []string) {
+	flags := flag.NewFlagSet("embeds", flag.ExitOnError)
+	var af analysisFlags
+	flags.BoolVar(&af.tests, "tests", false, "Load the test files of the packages too")
+	flags.Var(&af.queryDirs, "query-dir", "Directory to query from for go packages. Current dir if empty")
+	flags.StringVar(&af.build, "build", "", "Build flags to pass to Go build tool. Separated with spaces")
+	var rf renderFlags
+	rf.register(flags)
+	var of outputFlags
+	of.register(flags)
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, embedsUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	aProg, err := analysis.RunAnalysis(af.tests, af.buildFlags(), flags.Args(), af.singleDir())
+	check(err, "could not run program analysis: %v")
+	cytoGraph := render.NewCytoGraph()
+	cytoGraph.Meta.Mode = "embeds"
+	var named []*types.Named
+	for _, p := range aProg.Initial {
+		if p == nil {
+			continue
+		}
+		cytoGraph.Meta.Packages = append(cytoGraph.Meta.Packages, p.Pkg.Path())
+		scope := p.Pkg.Scope()
+		for _, name := range scope.Names() {
+			if obj, ok := scope.Lookup(name).(*types.TypeName); ok && !obj.IsAlias() {
+				if t, ok := obj.Type().(*types.Named); ok {
+					named = append(named, t)
+				}
+			}
+		}
+	}
+	opts := rf.options()
+	opts.Modules = aProg.Modules
+	cytoGraph.LoadEmbeddingGraph(named, opts)
+	writeGraph(cytoGraph, &of)
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/protolambda/gocyto/analysis"
+	"github.com/protolambda/gocyto/render"
+)
+
+// analysisFlags configure how the packages are loaded, and how their call graph is computed.
+type analysisFlags struct {
+	tests        bool
+	queryDirs    stringsFlag
+	repos        string
+	mode         string
+	roots        string
+	build        string
+	ptrReflect   bool
+	ptrLog       string
+	ptrQuery     string
+	timeout      time.Duration
+	budget       time.Duration
+	cache        bool
+	cacheDir     string
+	allConfigs   bool
+	noInits      bool
+	plugins      string
+	featureFlags string
+	polymorphic  int
+	implements   bool
+	panics       bool
+	globals      bool
+	channels     bool
+	contextChk   bool
+}
+
+func (f *analysisFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.tests, "tests", false, "Consider tests files as entry points for call-graph")
+	fs.Var(&f.queryDirs, "query-dir", "Directory to query from for go packages. Current dir if empty. Repeat to analyze multiple repositories, and merge their graphs")
+	fs.StringVar(&f.repos, "repos", "", "File listing directories of repositories to analyze, one per line, relative to the file. Merged like repeated -query-dir")
+	fs.StringVar(&f.mode, "mode", analysis.DefaultAnalysis.String(), "Type of analysis to run. One of: cha, rta, static, pointer (deprecated)")
+	fs.StringVar(&f.roots, "roots", "", "Fully qualified names of functions to start from instead of main and init, e.g. (*example.com/pkg.Server).Serve. Separated with commas")
+	fs.BoolVar(&f.noInits, "no-inits", false, "Exclude package initializers, and the functions only reachable from them, from the roots and the call graph")
+	fs.StringVar(&f.plugins, "plugins", "", "Package patterns of Go plugins of the program, to merge into the graph, with plugin-boundary edges at plugin.Lookup calls. Separated with commas")
+	fs.StringVar(&f.featureFlags, "feature-flags", "", "Fully qualified names of feature-flag accessor functions, to mark the calls guarded by conditions on their results, and the functions only reachable through them. Separated with commas")
+	fs.IntVar(&f.polymorphic, "polymorphic", 0, "Report this many dynamic call sites with the most callees, and a histogram of the numbers of callees of all dynamic call sites, in the polymorphism report. Disabled if 0")
+	fs.BoolVar(&f.implements, "implements", false, "Add the interfaces of the packages, with dashed implements edges from the receiver types in the graph that implement them, to see the structure of dynamic dispatch")
+	fs.BoolVar(&f.panics, "panics", false, "Mark the functions that call panic, of which the panic can escape to main or another root, as no function on a call path to them defers a recover. Listed in the panics report")
+	fs.BoolVar(&f.channels, "channels", false, "Add the channels, by where they are made, or else by type, with edges from the functions that send to and close them, to the functions that receive from them")
+	fs.BoolVar(&f.globals, "globals", false, "Add the package-level variables, with edges from the functions that read and write them, to find hidden shared state")
+	fs.BoolVar(&f.contextChk, "context-check", false, "Mark the calls of functions that accept a context.Context, from functions without a context, or that pass context.Background or context.TODO instead of their own, as warnings")
+	fs.StringVar(&f.build, "build", "", "Build flags to pass to Go build tool. Separated with spaces")
+	fs.BoolVar(&f.ptrReflect, "pointer-reflection", false, "Analyze reflection calls in pointer mode. More precise, but much more expensive")
+	fs.StringVar(&f.ptrLog, "pointer-log", "", "File to write the (very verbose) pointer analysis log to")
+	fs.StringVar(&f.ptrQuery, "pointer-query", "", "Package-level variables (import/path.Name) to print the points-to set of in pointer mode, to std err. Separated with commas")
+	fs.DurationVar(&f.timeout, "timeout", 0, "Maximum duration of the call graph analysis, after which it falls back to the static call graph. No limit if 0")
+	fs.DurationVar(&f.budget, "analysis-budget", 0, "Maximum duration of each analysis attempt, after which the analysis is retried with less precision: pointer analysis without reflection, then rta, then cha. Overrides -timeout. No limit if 0")
+	fs.BoolVar(&f.allConfigs, "all-build-configs", false, "Analyze the packages for linux/amd64, linux/arm64, darwin/amd64 and windows/amd64, and merge the graphs. Nodes list the platforms they are found on")
+	fs.BoolVar(&f.cache, "cache", false, "Reuse the graph of a previous run with the same analysis options, if the program did not change. The complete graph is cached, and filtered with the render options")
+	fs.StringVar(&f.cacheDir, "cache-dir", "", "Directory to cache graphs in. User cache dir if empty")
+}
+
+// stringsFlag is a flag that can be repeated, to collect multiple values.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// dirs returns the directories to query packages from, of each repository to analyze: the current dir if none are set.
+// Exits if the repos file cannot be read.
+func (f *analysisFlags) dirs() []string {
+	dirs := append([]string(nil), f.queryDirs...)
+	if f.repos != "" {
+		data, err := ioutil.ReadFile(f.repos)
+		check(err, "could not read repos file: %v")
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				dirs = append(dirs, filepath.Join(filepath.Dir(f.repos), line))
+			}
+		}
+	}
+	if len(dirs) == 0 {
+		return []string{""}
+	}
+	return dirs
+}
+
+// singleDir returns the directory to query packages from, for commands that analyze a single repository.
+// Exits if multiple are set.
+func (f *analysisFlags) singleDir() string {
+	dirs := f.dirs()
+	if len(dirs) > 1 {
+		_, _ = fmt.Fprintln(os.Stderr, "only a single -query-dir is supported by this command")
+		os.Exit(2)
+	}
+	return dirs[0]
+}
+
+func (f *analysisFlags) buildFlags() []string {
+	if len(f.build) > 0 {
+		return strings.Split(f.build, " ")
+	}
+	return nil
+}
+
+func (f *analysisFlags) pluginPatterns() []string {
+	if len(f.plugins) > 0 {
+		return strings.Split(f.plugins, ",")
+	}
+	return nil
+}
+
+// buildConfigs returns the platforms to analyze the packages for. A nil config is the host platform.
+func (f *analysisFlags) buildConfigs() []*analysis.BuildConfig {
+	if !f.allConfigs {
+		return []*analysis.BuildConfig{nil}
+	}
+	var out []*analysis.BuildConfig
+	for i := range analysis.DefaultBuildConfigs {
+		out = append(out, &analysis.DefaultBuildConfigs[i])
+	}
+	return out
+}
+
+// loadOptions returns the options to load the complete graph with, before it is filtered with the render options:
+// everything is included, and the analysis flags that add to the graph while it is loaded are set.
+func (f *analysisFlags) loadOptions() *render.RenderOptions {
+	return &render.RenderOptions{
+		IncludeGoRoot:     true,
+		IncludeUnexported: true,
+		ContextCheck:      f.contextChk,
+		Globals:           f.globals,
+		Channels:          f.channels,
+	}
+}
+
+// analysisMode parses the mode, and warns if it is deprecated. Exits if the mode is not recognized.
+func (f *analysisFlags) analysisMode() analysis.AnalysisMode {
+	mode, err := analysis.ParseAnalysisMode(f.mode)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if msg := mode.Deprecation(); msg != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+	}
+	return mode
+}
+
+// renderFlags configure which parts of the call graph are output.
+type renderFlags struct {
+	granularity string
+	goRoot      bool
+	unexported  bool
+	includePkg  string
+	excludePkg  string
+	includeFunc string
+	excludeFunc string
+	noDoubles   bool
+	withinMod   bool
+	crossPkg    bool
+	pkg         string
+	mergeCalls  bool
+	foldInsts   bool
+	goroutines  bool
+	errorPaths  bool
+	unsafe      bool
+	noDeferred  bool
+	focus       string
+	hops        int
+	gatedBy     string
+	condense    bool
+	nestPaths   bool
+	nestMods    bool
+	dominators  bool
+	betweenness bool
+	samples     int
+	rank        bool
+	clusters    bool
+}
+
+func (f *renderFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.granularity, "granularity", string(render.FunctionGranularity), "Level to group functions at. One of: function (in their receiver type), file (in their source file), type (calls between receiver types, counted), package (calls between packages, counted)")
+	fs.BoolVar(&f.goRoot, "go-root", false, "Include packages part of the Go root")
+	fs.BoolVar(&f.unexported, "unexported", false, "Include unexported function calls")
+	fs.StringVar(&f.includePkg, "include-pkg", "", "Regular expression of package paths to only include calls between, e.g. ^github.com/org/")
+	fs.StringVar(&f.includeFunc, "include-func", "", "Regular expression of fully qualified function names to only include calls between")
+	fs.StringVar(&f.excludeFunc, "exclude-func", "", "Regular expression of fully qualified function names to exclude calls from and to, e.g. \\.(Marshal|Unmarshal)$")
+	fs.StringVar(&f.focus, "focus", "", "Only include the callers and callees near this function, e.g. (*pkg.Server).Serve or the fully qualified name")
+	fs.IntVar(&f.hops, "hops", 2, "Number of calls away from the focused function to include callers and callees of")
+	fs.StringVar(&f.gatedBy, "gated-by", "", "Only include the calls to functions gated by this feature-flag accessor, as found with -feature-flags")
+	fs.BoolVar(&f.nestMods, "nest-modules", false, "Nest packages in a node of their module, labeled with the module path and version. Combined with -nest-paths, packages without module are nested by path")
+	fs.BoolVar(&f.nestPaths, "nest-paths", false, "Nest packages in nodes of the segments of their import path, e.g. github.com > org > repo > pkg, to group related packages")
+	fs.BoolVar(&f.condense, "condense", false, "Collapse each strongly connected component, functions that call each other in a cycle, into a single node, sized by the number of functions")
+	fs.BoolVar(&f.dominators, "dominators", false, "Output the dominator tree instead of the calls: edges from each function to the functions that are only reachable through it")
+	fs.BoolVar(&f.betweenness, "betweenness", false, "Compute the betweenness centrality of functions, the fraction of shortest call paths through them, to find choke points")
+	fs.IntVar(&f.samples, "betweenness-samples", 500, "Number of functions to approximate the betweenness centrality from the shortest paths of. Exact if 0")
+	fs.BoolVar(&f.rank, "rank", false, "Compute the PageRank of functions, with the calls as links, to size them by importance")
+	fs.BoolVar(&f.clusters, "clusters", false, "Assign functions to clusters of closely connected functions, across packages, with label propagation")
+	fs.BoolVar(&f.noDeferred, "exclude-deferred", false, "Exclude the calls of defer statements, which clutter cleanup-heavy code")
+	fs.BoolVar(&f.unsafe, "unsafe", false, "Only include the calls on call paths to functions that use unsafe.Pointer, to audit the code that can reach them")
+	fs.BoolVar(&f.errorPaths, "error-paths", false, "Only include the calls between functions that return errors, reachable from exported functions, to see where errors are produced and where they surface")
+	fs.BoolVar(&f.goroutines, "goroutines", false, "Only include the calls started as goroutine with a go statement, to see the concurrency of the program")
+	fs.BoolVar(&f.mergeCalls, "merge-calls", false, "Merge the calls from the same caller to the same callee, at different call sites, into one edge, with the number of calls as count")
+	fs.BoolVar(&f.foldInsts, "fold-instantiations", false, "Merge the instantiations of each generic function or method into one node, and their calls into one edge per caller and callee, listing the type arguments as type_args")
+	fs.StringVar(&f.pkg, "package", "", "Only include calls within the package with this import path, exported or not, to deep-dive a single component")
+	fs.BoolVar(&f.crossPkg, "cross-package", false, "Only include calls between functions of different packages, for a concise view of the interactions between packages")
+	fs.BoolVar(&f.withinMod, "within-module", false, "Exclude calls to functions outside of the analyzed module, of the standard library and of other modules, to only see the structure of your own code")
+	fs.BoolVar(&f.noDoubles, "exclude-test-doubles", false, "Exclude calls from and to mocks, fakes and stubs, recognized by generated code headers, file names and type names")
+	fs.StringVar(&f.excludePkg, "exclude-pkg", "", "Regular expression of package paths to exclude calls from and to, e.g. ^github.com/aws/")
+}
+
+// options converts the flags to render options.
+// Exits if the granularity is not recognized, or if a pattern is not a valid regular expression.
+func (f *renderFlags) options() *render.RenderOptions {
+	granularity, err := render.ParseGranularity(f.granularity)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// all functions of a single package are of interest, exported or not, wherever the package is from
+	singlePkg := f.pkg != ""
+	return &render.RenderOptions{
+		Granularity:        granularity,
+		IncludeGoRoot:      f.goRoot || singlePkg,
+		IncludeUnexported:  f.unexported || singlePkg,
+		Package:            f.pkg,
+		MergeCalls:         f.mergeCalls,
+		FoldInstantiations: f.foldInsts,
+		Goroutines:         f.goroutines,
+		ErrorPaths:         f.errorPaths,
+		Unsafe:             f.unsafe,
+		ExcludeDeferred:    f.noDeferred,
+		IncludePkg:         compilePattern("include-pkg", f.includePkg),
+		ExcludePkg:         compilePattern("exclude-pkg", f.excludePkg),
+		IncludeFunc:        compilePattern("include-func", f.includeFunc),
+		ExcludeFunc:        compilePattern("exclude-func", f.excludeFunc),
+		ExcludeTestDoubles: f.noDoubles,
+		WithinModule:       f.withinMod,
+		CrossPackage:       f.crossPkg,
+		Focus:              f.focus,
+		FocusHops:          f.hops,
+		GatedBy:            f.gatedBy,
+		NestModules:        f.nestMods,
+		NestPaths:          f.nestPaths,
+		Condense:           f.condense,
+		Dominators:         f.dominators,
+		Betweenness:        f.betweenness,
+		BetweennessSamples: f.samples,
+		Rank:               f.rank,
+		Clusters:           f.clusters,
+	}
+}
+
+// compilePattern compiles the regular expression of the flag, or returns nil if it is empty.
+func compilePattern(name string, pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "invalid -%s pattern: %v\n", name, err)
+		os.Exit(2)
+	}
+	return re
+}
+
+// outputFlags configure where, and in what format, the graph is written.
+type outputFlags struct {
+	web       bool
+	format    string
+	out       string
+	summarize int
+	pretty    bool
+	redact    bool
+	sort      string
+	srcURL    string
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.web, "web", false, "Output an index.html with graph data embedded instead of raw JSON")
+	fs.StringVar(&f.format, "format", "json", "Output format. One of: json, web (same as -web), widget (a read-only HTML snippet to embed in wikis, loading the graph JSON written next to it, requires -out)")
+	fs.StringVar(&f.out, "out", "", "Output file, if none is specified, output to std out")
+	fs.BoolVar(&f.pretty, "pretty", false, "Indent the graph JSON, and sort the keys of all objects, e.g. to review changes to a checked in graph")
+	fs.BoolVar(&f.redact, "redact", false, "Replace the names of functions, types, packages, files, modules and local replacement directories with stable pseudonyms, and leave out signatures and reports, to share the graph without revealing identifiers")
+	fs.StringVar(&f.sort, "sort", "id", "Order of the nodes in the output, of the functions in the outline, and in the text listings of callers, callees and path. One of: id (the order they were found in, functions are listed by name), name, package, fan-in, fan-out, complexity, lines, rank, betweenness. Names and packages are compared bytewise, not by locale. Metrics sort highest first")
+	fs.StringVar(&f.srcURL, "src-url", "", "Template of links to the source of functions, e.g. https://github.com/org/repo/blob/{commit}/{file}#L{line}-L{endLine}. The file is relative to the root of its git repository, the commit is the checked out one")
+	fs.IntVar(&f.summarize, "summarize", 0, "Replace the call subtrees of at most this many functions, only called through their top function, with supernodes, and write the graph of each to a detail file next to the output. Requires -out. Disabled if 0")
+}
+
+// sortOrder returns the order to output the nodes in. Exits if the order is not recognized.
+func (f *outputFlags) sortOrder() render.SortOrder {
+	order, err := render.ParseSortOrder(f.sort)
+	check(err, "%v")
+	return order
+}
+
+// outputFormat returns the format to write the graph in. Exits if the format is not recognized.
+func (f *outputFlags) outputFormat() string {
+	if f.web {
+		return "web"
+	}
+	switch f.format {
+	case "json", "web", "widget":
+		return f.format
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "output format not recognized: %q\n", f.format)
+	os.Exit(2)
+	return ""
+}
@@ -0,0 +1,114 @@
+// Package gocytotest asserts call relationships in tests, to encode architectural intent in a test suite.
+//
+//	func TestLayers(t *testing.T) {
+//		gocytotest.AssertCalls(t, "example.com/app/...", "api.Handle", "service.Run")
+//		gocytotest.AssertNotCalls(t, "example.com/app/...", "api.Handle", "(*db.Conn).Exec")
+//	}
+//
+// The call graph is computed with static analysis: cheap, but calls of interface methods and function values
+// are not resolved, only direct calls are asserted.
+package gocytotest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/protolambda/gocyto/analysis"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// loaded caches the call graph of each package pattern, for all assertions of the test binary.
+var loaded struct {
+	sync.Mutex
+	graphs map[string]*callgraph.Graph
+}
+
+// callGraph loads the packages of the pattern, and computes their static call graph, or returns it from cache.
+func callGraph(t testing.TB, pkgPattern string) *callgraph.Graph {
+	t.Helper()
+	loaded.Lock()
+	defer loaded.Unlock()
+	if cg, ok := loaded.graphs[pkgPattern]; ok {
+		return cg
+	}
+	data, err := analysis.RunAnalysis(false, nil, []string{pkgPattern}, "")
+	if err != nil {
+		t.Fatalf("could not load %s: %v", pkgPattern, err)
+	}
+	cg, err := analysis.StaticAnalysis.ComputeCallgraph(data)
+	if err != nil {
+		t.Fatalf("could not compute call graph of %s: %v", pkgPattern, err)
+	}
+	if loaded.graphs == nil {
+		loaded.graphs = make(map[string]*callgraph.Graph)
+	}
+	loaded.graphs[pkgPattern] = cg
+	return cg
+}
+
+// shortName drops the package path, except the package name, from a fully qualified function name,
+// e.g. "(*example.com/pkg.Server).Serve" becomes "(*pkg.Server).Serve".
+func shortName(name string) string {
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return name
+	}
+	start := strings.LastIndexAny(name[:i], "(*") + 1
+	return name[:start] + name[i+1:]
+}
+
+// funcsNamed returns the nodes of the functions with the name, fully qualified, or with just the package name.
+// Fails the test if there are none.
+func funcsNamed(t testing.TB, cg *callgraph.Graph, name string) []*callgraph.Node {
+	t.Helper()
+	var out []*callgraph.Node
+	for fn, n := range cg.Nodes {
+		if fn != nil && (fn.String() == name || shortName(fn.String()) == name) {
+			out = append(out, n)
+		}
+	}
+	if len(out) == 0 {
+		t.Fatalf("no function named %s", name)
+	}
+	return out
+}
+
+// calls returns the call sites from a function named caller to a function named callee.
+func calls(t testing.TB, pkgPattern string, caller string, callee string) []*callgraph.Edge {
+	t.Helper()
+	cg := callGraph(t, pkgPattern)
+	callees := make(map[*ssa.Function]bool)
+	for _, n := range funcsNamed(t, cg, callee) {
+		callees[n.Func] = true
+	}
+	var out []*callgraph.Edge
+	for _, n := range funcsNamed(t, cg, caller) {
+		for _, e := range n.Out {
+			if callees[e.Callee.Func] {
+				out = append(out, e)
+			}
+		}
+	}
+	return out
+}
+
+// AssertCalls fails the test if the caller does not call the callee directly.
+// Functions are named fully qualified, or with just the package name, e.g. (*pkg.Server).Serve
+func AssertCalls(t testing.TB, pkgPattern string, caller string, callee string) {
+	t.Helper()
+	if len(calls(t, pkgPattern, caller, callee)) == 0 {
+		t.Errorf("expected %s to call %s", caller, callee)
+	}
+}
+
+// AssertNotCalls fails the test if the caller calls the callee directly, and reports the positions of the calls.
+// Functions are named fully qualified, or with just the package name, e.g. (*pkg.Server).Serve
+func AssertNotCalls(t testing.TB, pkgPattern string, caller string, callee string) {
+	t.Helper()
+	for _, e := range calls(t, pkgPattern, caller, callee) {
+		t.Errorf("expected %s not to call %s, but it does at %s",
+			caller, callee, e.Caller.Func.Prog.Fset.Position(e.Pos()))
+	}
+}
@@ -0,0 +1,106 @@
+package gocytotest
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/static"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// recorder records the failures of assertions, instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestShortName(t *testing.T) {
+	cases := []struct {
+		name  string
+		short string
+	}{
+		{"main.main", "main.main"},
+		{"example.com/pkg.Run", "pkg.Run"},
+		{"(*example.com/pkg.Server).Serve", "(*pkg.Server).Serve"},
+		{"(example.com/org/pkg.Config).String", "(pkg.Config).String"},
+		{"example.com/pkg.Run$1", "pkg.Run$1"},
+	}
+	for _, c := range cases {
+		if got := shortName(c.name); got != c.short {
+			t.Errorf("got short name %q of %q, expected %q", got, c.name, c.short)
+		}
+	}
+}
+
+// testPkg is the pattern the call graph of testSrc is cached under, as if it was loaded.
+const testPkg = "example.com/app"
+
+const testSrc = `package app
+
+type Server struct{}
+
+func (s *Server) Serve() { handle() }
+
+func handle() { query(); query() }
+
+func query() {}
+
+func Run() { (&Server{}).Serve() }
+`
+
+// cacheTestGraph computes the static call graph of testSrc, and caches it for the pattern testPkg.
+func cacheTestGraph(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "app.go", testSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := types.NewPackage(testPkg, "app")
+	ssaPkg, _, err := ssautil.BuildPackage(&types.Config{}, fset, pkg, []*ast.File{f}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.Lock()
+	defer loaded.Unlock()
+	if loaded.graphs == nil {
+		loaded.graphs = make(map[string]*callgraph.Graph)
+	}
+	loaded.graphs[testPkg] = static.CallGraph(ssaPkg.Prog)
+}
+
+func TestAssertions(t *testing.T) {
+	cacheTestGraph(t)
+	cases := []struct {
+		name   string
+		assert func(t testing.TB, pkgPattern string, caller string, callee string)
+		caller string
+		callee string
+		// failures is the number of errors the assertion reports
+		failures int
+	}{
+		{"calls", AssertCalls, "app.handle", "app.query", 0},
+		{"calls method", AssertCalls, "(*app.Server).Serve", "app.handle", 0},
+		{"calls fully qualified", AssertCalls, "example.com/app.Run", "(*example.com/app.Server).Serve", 0},
+		{"calls indirectly", AssertCalls, "app.Run", "app.handle", 1},
+		{"not calls", AssertNotCalls, "app.Run", "app.query", 0},
+		{"not calls, but calls twice", AssertNotCalls, "app.handle", "app.query", 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			c.assert(r, testPkg, c.caller, c.callee)
+			if len(r.errors) != c.failures {
+				t.Errorf("got %d failures, expected %d: %v", len(r.errors), c.failures, r.errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package graph
+
+import "sort"
+
+const maxPropagationRounds = 100
+
+// LabelPropagation detects communities in the graph, ignoring edge directions.
+// Every node starts in its own community, and repeatedly joins the community most of its neighbours are in,
+// until no node changes community. Visiting order and ties are resolved by node number, to keep results stable.
+// Returns the community of each node, numbered 0 to k-1 in order of first occurrence.
+func LabelPropagation(g *Digraph) []int {
+	weights := g.Weights()
+	labels := make([]int, g.N())
+	for i := range labels {
+		labels[i] = i
+	}
+	for round := 0; round < maxPropagationRounds; round++ {
+		changed := false
+		for i, neighbours := range weights {
+			if len(neighbours) == 0 {
+				continue
+			}
+			scores := make(map[int]int)
+			for j, w := range neighbours {
+				scores[labels[j]] += w
+			}
+			bestScore := 0
+			for _, score := range scores {
+				if score > bestScore {
+					bestScore = score
+				}
+			}
+			// stay in the current community if it is among the best, otherwise pick the lowest best label.
+			best := labels[i]
+			if scores[best] < bestScore {
+				best = -1
+				for label, score := range scores {
+					if score == bestScore && (best < 0 || label < best) {
+						best = label
+					}
+				}
+			}
+			if best != labels[i] {
+				labels[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return normalizeLabels(labels)
+}
+
+func normalizeLabels(labels []int) []int {
+	renamed := make(map[int]int)
+	out := make([]int, len(labels))
+	for i, l := range labels {
+		n, ok := renamed[l]
+		if !ok {
+			n = len(renamed)
+			renamed[l] = n
+		}
+		out[i] = n
+	}
+	return out
+}
+
+// Groups lists the members of each community, as returned by LabelPropagation.
+func Groups(labels []int) [][]int {
+	var groups [][]int
+	for i, l := range labels {
+		for len(groups) <= l {
+			groups = append(groups, nil)
+		}
+		groups[l] = append(groups[l], i)
+	}
+	for _, g := range groups {
+		sort.Ints(g)
+	}
+	return groups
+}
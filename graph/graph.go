@@ -0,0 +1,50 @@
+// Package graph implements graph algorithms on a minimal directed graph,
+// shared by the analysis (SSA call-graphs) and render (cyto graphs) packages.
+package graph
+
+// Digraph is a directed graph with nodes numbered 0 to N-1. Parallel edges are allowed.
+type Digraph struct {
+	Out [][]int
+}
+
+func NewDigraph(n int) *Digraph {
+	return &Digraph{Out: make([][]int, n)}
+}
+
+func (g *Digraph) N() int {
+	return len(g.Out)
+}
+
+func (g *Digraph) AddEdge(from int, to int) {
+	g.Out[from] = append(g.Out[from], to)
+}
+
+// In computes the incoming adjacency lists of the graph.
+func (g *Digraph) In() [][]int {
+	in := make([][]int, len(g.Out))
+	for from, outs := range g.Out {
+		for _, to := range outs {
+			in[to] = append(in[to], from)
+		}
+	}
+	return in
+}
+
+// Weights computes the undirected edge weights of the graph: the number of edges between each pair of nodes,
+// in either direction. Self-loops are ignored.
+func (g *Digraph) Weights() []map[int]int {
+	w := make([]map[int]int, len(g.Out))
+	for i := range w {
+		w[i] = make(map[int]int)
+	}
+	for from, outs := range g.Out {
+		for _, to := range outs {
+			if from == to {
+				continue
+			}
+			w[from][to]++
+			w[to][from]++
+		}
+	}
+	return w
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/protolambda/gocyto/render"
+)
+
+// graphStore fetches graph JSON artifacts from a storage backend.
+type graphStore interface {
+	// fetch returns the contents and the version tag of the artifact, or notModified if it is still at the given version.
+	fetch(version string) (data []byte, newVersion string, notModified bool, err error)
+}
+
+// fileStore reads graphs from the local file system, versioned by modification time and size.
+type fileStore struct {
+	path string
+}
+
+func (s *fileStore) fetch(version string) ([]byte, string, bool, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, "", false, err
+	}
+	newVersion := fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+	if newVersion == version {
+		return nil, version, true, nil
+	}
+	data, err := ioutil.ReadFile(s.path)
+	return data, newVersion, false, err
+}
+
+// httpStore downloads graphs over HTTP(S), revalidated with the ETag of the previous download.
+type httpStore struct {
+	url string
+}
+
+func (s *httpStore) fetch(version string) ([]byte, string, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if version != "" {
+		req.Header.Set("If-None-Match", version)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, version, true, nil
+	case http.StatusOK:
+		data, err := ioutil.ReadAll(resp.Body)
+		return data, resp.Header.Get("ETag"), false, err
+	default:
+		return nil, "", false, fmt.Errorf("could not download %s: %s", s.url, resp.Status)
+	}
+}
+
+// openGraphStore returns the storage backend of the location: a local file path, an http(s):// URL,
+// or an s3://bucket/key or gs://bucket/key object, which are downloaded from the public HTTPS endpoints of their bucket.
+func openGraphStore(location string) graphStore {
+	switch {
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return &httpStore{url: location}
+	case strings.HasPrefix(location, "s3://"):
+		bucket, key := splitBucketKey(strings.TrimPrefix(location, "s3://"))
+		return &httpStore{url: "https://" + bucket + ".s3.amazonaws.com/" + key}
+	case strings.HasPrefix(location, "gs://"):
+		bucket, key := splitBucketKey(strings.TrimPrefix(location, "gs://"))
+		return &httpStore{url: "https://storage.googleapis.com/" + bucket + "/" + key}
+	default:
+		return &fileStore{path: location}
+	}
+}
+
+func splitBucketKey(path string) (bucket string, key string) {
+	if i := strings.Index(path, "/"); i >= 0 {
+		return path[:i], path[i+1:]
+	}
+	return path, ""
+}
+
+// cachedGraph keeps the page rendered from the last fetched version of a graph, and revalidates the graph with the store
+// when it is older than maxAge. Pages are rendered once per version, and shared by all requests, unlike graphs,
+// which are modified while they are written.
+type cachedGraph struct {
+	location string
+	store    graphStore
+	maxAge   time.Duration
+	render   func(*render.CytoGraph) ([]byte, error)
+
+	mu      sync.Mutex
+	page    []byte
+	version string
+	checked time.Time
+}
+
+func newCachedGraph(location string, maxAge time.Duration, render func(*render.CytoGraph) ([]byte, error)) *cachedGraph {
+	return &cachedGraph{location: location, store: openGraphStore(location), maxAge: maxAge, render: render}
+}
+
+// get returns the page of the graph, rendered again if the graph changed.
+// If revalidation fails, the cached page is returned, if any.
+func (c *cachedGraph) get() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.page != nil && time.Since(c.checked) < c.maxAge {
+		return c.page, nil
+	}
+	data, version, notModified, err := c.store.fetch(c.version)
+	if err != nil {
+		if c.page != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "warning: could not revalidate %s, serving cached graph: %v\n", c.location, err)
+			return c.page, nil
+		}
+		return nil, err
+	}
+	c.checked = time.Now()
+	if notModified && c.page != nil {
+		return c.page, nil
+	}
+	graph, err := render.ReadJson(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not read graph %s: %v", c.location, err)
+	}
+	page, err := c.render(graph)
+	// the page does not reference the graph, so its nodes and edges can be reused for the next version.
+	graph.Release()
+	if err != nil {
+		return nil, fmt.Errorf("could not render graph %s: %v", c.location, err)
+	}
+	c.page, c.version = page, version
+	return page, nil
+}
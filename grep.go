@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/protolambda/gocyto/render"
+)
+
+const grepUsage = `
+Search the functions of a graph, by fully qualified name, receiver type name, or package path,
+and list them with their positions and the numbers of calls to and from them. E.g. to find what to -focus on next.
+
+Usage:
+
+gocyto grep [options...] <regex> <graph.json>
+gocyto grep [options...] <regex> <package path(s)>
+
+Options:
+
+`
+
+func grepCmd(args []string) {
+	flags := flag.NewFlagSet("grep", flag.ExitOnError)
+	var af analysisFlags
+	af.register(flags)
+	var pf profileFlags
+	pf.register(flags)
+	jsonFlag := flags.Bool("json", false, "Output the matches as JSON instead of text")
+	outFlag := flags.String("out", "", "Output file, if none is specified, output to std out")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, grepUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	re, err := regexp.Compile(flags.Arg(0))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "invalid pattern: %v\n", err)
+		os.Exit(2)
+	}
+
+	stopProfiles := pf.start()
+	defer stopProfiles()
+
+	var cytoGraph *render.CytoGraph
+	if rest := flags.Args()[1:]; len(rest) == 1 && strings.HasSuffix(rest[0], ".json") {
+		cytoGraph, err = readGraphFile(rest[0])
+		check(err, "could not read graph: %v")
+	} else {
+		// search everything in the program
+		opts := &render.RenderOptions{
+			IncludeGoRoot:     true,
+			IncludeUnexported: true,
+		}
+		cytoGraph = analyzeGraph(flags, &af, rest, opts)
+	}
+	matches := cytoGraph.Grep(re)
+	output(*outFlag, func(w io.Writer) {
+		if *jsonFlag {
+			check(json.NewEncoder(w).Encode(matches), "could not write matches JSON: %v")
+		} else {
+			check(render.WriteSymbolMatchesText(w, matches), "could not write matches: %v")
+		}
+	})
+	if len(matches) == 0 {
+		os.Exit(1)
+	}
+}
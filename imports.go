@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+
+	"github.com/protolambda/gocyto/analysis"
+	"github.com/protolambda/gocyto/render"
+)
+
+const importsUsage = `
+Render the package import graph instead of the call graph: the packages, and the packages they import,
+transitively, with an edge from each package to each of its imports.
+
+Usage:
+
+gocyto imports [options...] <package path(s)>
+
+Options:
+
+`
+
+func importsCmd(args []string) {
+	flags := flag.NewFlagSet("imports", flag.ExitOnError)
+	var af analysisFlags
+	flags.BoolVar(&af.tests, "tests", false, "Load the test files of the packages too")
+	flags.Var(&af.queryDirs, "query-dir", "Directory to query from for go packages. Current dir if empty")
+	flags.StringVar(&af.build, "build", "", "Build flags to pass to Go build tool. Separated with spaces")
+	var rf renderFlags
+	rf.register(flags)
+	var of outputFlags
+	of.register(flags)
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, importsUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	aProg, err := analysis.RunAnalysis(af.tests, af.buildFlags(), flags.Args(), af.singleDir())
+	check(err, "could not run program analysis: %v")
+	cytoGraph := render.NewCytoGraph()
+	cytoGraph.Meta.Mode = "imports"
+	var pkgs []*types.Package
+	for _, p := range aProg.Initial {
+		if p != nil {
+			pkgs = append(pkgs, p.Pkg)
+			cytoGraph.Meta.Packages = append(cytoGraph.Meta.Packages, p.Pkg.Path())
+		}
+	}
+	opts := rf.options()
+	opts.Modules = aProg.Modules
+	cytoGraph.LoadImportGraph(pkgs, opts)
+	writeGraph(cytoGraph, &of)
+}
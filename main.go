@@ -1,5 +1,4 @@
 // gocyto: Go call-graph analysis and visualization
-//
 package main
 
 import (
@@ -9,23 +8,30 @@ import (
 	"fmt"
 	"github.com/protolambda/gocyto/analysis"
 	"github.com/protolambda/gocyto/render"
+	"golang.org/x/tools/go/callgraph"
 	"html/template"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
 var (
-	webFlag        = flag.Bool("web", false, "Output an index.html with graph data embedded instead of raw JSON")
-	testFlag       = flag.Bool("tests", false, "Consider tests files as entry points for call-graph")
-	goRootFlag     = flag.Bool("go-root", false, "Include packages part of the Go root")
-	unexportedFlag = flag.Bool("unexported", false, "Include unexported function calls")
-	queryDir       = flag.String("query-dir", "", "Directory to query from for go packages. Current dir if empty")
-	modeFlag       = flag.String("mode", "pointer", "Type of analysis to run. One of: pointer, cha, rta, static")
-	buildFlag      = flag.String("build", "", "Build flags to pass to Go build tool. Separated with spaces")
-	outFlag        = flag.String("out", "", "Output file, if none is specified, output to std out")
+	analysisOpts analysisFlags
+	renderOpts   renderFlags
+	outputOpts   outputFlags
+	profileOpts  profileFlags
+	dryRunFlag   = flag.Bool("dry-run", false, "Only load the packages, and output counts and rough cost estimates of each analysis mode")
+	splitPkgFlag = flag.String("split-pkg", "", "Instead of the graph, output a suggestion of how to split up the package with this path")
 )
 
+func init() {
+	analysisOpts.register(flag.CommandLine)
+	renderOpts.register(flag.CommandLine)
+	outputOpts.register(flag.CommandLine)
+	profileOpts.register(flag.CommandLine)
+}
+
 const usage = `
 Gocyto: Callgraph analysis and visualization for Go - by @protolambda
 
@@ -34,17 +40,88 @@ https://github.com/protolambda/gocyto
 Usage:
 
 gocyto [options...] <package path(s)>
+gocyto analyze [options...] <package path(s)>
+gocyto render [options...] <graph.json>
+gocyto diff [options...] <old graph.json> <new graph.json>
+gocyto body [options...] <function> <package path(s)>
+gocyto path [options...] <from function> <to function> <package path(s)>
+gocyto stdlib [options...] <package path(s)>
+gocyto callers [options...] <function> <package path(s)>
+gocyto callees [options...] <function> <package path(s)>
+gocyto deadcode [options...] <package path(s)>
+gocyto rank [options...] <package path(s)>
+gocyto grep [options...] <regex> <graph.json | package path(s)>
+gocyto stats [options...] <graph.json | package path(s)>
+gocyto serve [options...] <graph location(s)>
+gocyto imports [options...] <package path(s)>
+gocyto embeds [options...] <package path(s)>
+gocyto spawners [options...] <package path(s)>
 
 Options:
 
 `
 
+// WidgetData is the data of the widget.gohtml template.
+type WidgetData struct {
+	// GraphURL is the URL of the graph JSON, relative to the widget.
+	GraphURL string
+}
+
 type WebData struct {
 	Packages  string
 	GraphJSON template.JS
+	// Outline is the text alternative of the graph, for screen readers and keyboard users.
+	Outline []render.OutlinePackage
+}
+
+// commands are run instead of the default call-graph output, when named by the first argument.
+var commands = map[string]func(args []string){
+	"analyze":  analyzeCmd,
+	"render":   renderCmd,
+	"diff":     diffCmd,
+	"body":     bodyCmd,
+	"path":     pathCmd,
+	"stdlib":   stdlibCmd,
+	"callers":  callersCmd,
+	"callees":  calleesCmd,
+	"deadcode": deadcodeCmd,
+	"rank":     rankCmd,
+	"grep":     grepCmd,
+	"stats":    statsCmd,
+	"serve":    serveCmd,
+	"imports":  importsCmd,
+	"embeds":   embedsCmd,
+	"spawners": spawnersCmd,
+}
+
+func check(err error, msg string) {
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, msg, err)
+		os.Exit(1)
+	}
+}
+
+// output runs the write function on the output file, or on std out if the path is empty.
+func output(outPath string, write func(w io.Writer)) {
+	if outPath == "" {
+		write(os.Stdout)
+		return
+	}
+	f, err := os.Create(outPath)
+	check(err, "could not create file: %v")
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	write(w)
+	check(w.Flush(), "could not flush output to file: %v")
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
+		}
+	}
 	flag.Parse()
 
 	args := flag.Args()
@@ -54,85 +131,313 @@ func main() {
 		os.Exit(2)
 	}
 
-	var buildFlags []string
-	if len(*buildFlag) > 0 {
-		buildFlags = strings.Split(*buildFlag, " ")
+	stopProfiles := profileOpts.start()
+	defer stopProfiles()
+
+	if *dryRunFlag {
+		aProg, err := analysis.RunAnalysis(analysisOpts.tests, analysisOpts.buildFlags(), args, analysisOpts.singleDir())
+		check(err, "could not run program analysis: %v")
+		estimate := analysis.EstimateCost(aProg)
+		output(outputOpts.out, func(w io.Writer) {
+			check(estimate.WriteText(w), "could not write cost estimate: %v")
+		})
+		return
 	}
 
-	var mode analysis.AnalysisMode
-	switch *modeFlag {
-	case "pointer":
-		mode = analysis.PointerAnalysis
-	case "cha":
-		mode = analysis.ClassHierarchyAnalysis
-	case "rta":
-		mode = analysis.RapidTypeAnalysis
-	case "static":
-		mode = analysis.StaticAnalysis
-	default:
-		_, _ = fmt.Fprintf(os.Stderr, "analysis mode not recognized")
-		os.Exit(2)
+	if *splitPkgFlag != "" {
+		_, _, callGraph, failure := computeCallGraph(&analysisOpts, analysisOpts.analysisMode(), nil, analysisOpts.singleDir(), args)
+		check(failure, "could not compute call graph: %v")
+		suggestion, err := analysis.SuggestSplit(callGraph, *splitPkgFlag)
+		check(err, "could not suggest package split: %v")
+		output(outputOpts.out, func(w io.Writer) {
+			check(suggestion.WriteText(w), "could not write split suggestion: %v")
+		})
+		return
 	}
 
-	check := func(err error, msg string) {
-		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, msg, err)
-			os.Exit(1)
+	cytoGraph := analyzeGraph(flag.CommandLine, &analysisOpts, args, renderOpts.options())
+	writeGraph(cytoGraph, &outputOpts)
+}
+
+// analyzeGraph computes the call graph, and renders it with the options, or reuses a cached graph if enabled.
+// The analysis flags of the flag set are part of the cache key. The complete graph is loaded, and cached,
+// and filtered once with the options afterwards, so one cached graph serves any filter.
+func analyzeGraph(fs *flag.FlagSet, af *analysisFlags, args []string, opts *render.RenderOptions) *render.CytoGraph {
+	mode := af.analysisMode()
+
+	cacheFile := ""
+	if af.cache {
+		var err error
+		cacheFile, err = graphCachePath(fs, af, args)
+		check(err, "could not compute cache key: %v")
+		if cytoGraph := readGraphCache(cacheFile); cytoGraph != nil {
+			cytoGraph.Filter(opts)
+			checkFocus(cytoGraph, opts)
+			return cytoGraph
 		}
 	}
-	aProg, err := analysis.RunAnalysis(*testFlag, buildFlags, args, *queryDir)
-	check(err, "could not run program analysis: %v")
+	loadOpts := af.loadOptions()
 
-	callGraph := mode.ComputeCallgraph(aProg)
+	// failed analyses fall back to the static call graph, they are listed in the fallbacks of the graph metadata
 	cytoGraph := render.NewCytoGraph()
+	// graphs of all repositories, of all platforms, and of the plugins, are merged into the same cyto graph
+	dirs := af.dirs()
+	for _, dir := range dirs {
+		loadOpts.Repo = ""
+		if len(dirs) > 1 {
+			loadOpts.Repo = dir
+			cytoGraph.Meta.Repos = append(cytoGraph.Meta.Repos, dir)
+		}
+		for _, config := range af.buildConfigs() {
+			aProg, used, callGraph, _ := computeCallGraph(af, mode, config, dir, args)
+			renderGraph(cytoGraph, used, aProg, callGraph, loadOpts)
+
+			if plugins := af.pluginPatterns(); len(plugins) > 0 {
+				pluginProg, used, pluginGraph, _ := computeCallGraph(af, mode, config, dir, plugins)
+				renderGraph(cytoGraph, used, pluginProg, pluginGraph, loadOpts)
+				for _, link := range aProg.PluginLinks(pluginProg) {
+					cytoGraph.LinkFuncs(link.Caller.String(), link.Callee.String(), "plugin-boundary")
+				}
+			}
+		}
+	}
+	// partial graphs, and graphs of fallback analyses, are not cached: a retry may be more successful.
+	if cacheFile != "" && !cytoGraph.Meta.Partial && len(cytoGraph.Meta.Fallbacks) == 0 {
+		writeGraphCache(cacheFile, cytoGraph)
+	}
+	// the graphs of all loads are filtered together, filtering is not repeated for each load
+	cytoGraph.Filter(opts)
+	checkFocus(cytoGraph, opts)
+	return cytoGraph
+}
 
-	opts := &render.RenderOptions{
-		IncludeGoRoot:     *goRootFlag,
-		IncludeUnexported: *unexportedFlag,
+// checkFocus exits if no calls are left around the focused function, e.g. because its name is misspelled,
+// instead of writing an empty graph.
+func checkFocus(cytoGraph *render.CytoGraph, opts *render.RenderOptions) {
+	if opts.Focus != "" && len(cytoGraph.Edges) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "no calls found near %s\n", opts.Focus)
+		os.Exit(1)
 	}
+}
 
-	check(cytoGraph.LoadCallGraph(callGraph, opts), "could not call graph: %v")
+// computeCallGraph loads the packages for the platform (host platform if nil), and computes their call graph.
+// The mode of the call graph is returned, it is less precise than the requested mode if the analysis budget is exceeded.
+// If the analysis fails, the failure is returned with the static call graph it fell back to. Exits if there is no graph at all.
+func computeCallGraph(af *analysisFlags, mode analysis.AnalysisMode, config *analysis.BuildConfig, dir string, args []string) (*analysis.ProgramAnalysis, analysis.AnalysisMode, *callgraph.Graph, error) {
+	aProg, err := analysis.RunAnalysisFor(config, af.tests, af.buildFlags(), args, dir)
+	check(err, "could not run program analysis: %v")
+	for _, w := range aProg.Warnings {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
 
-	writeAsHtml := func(w io.Writer) {
-		tmpl := template.Must(template.ParseFiles("index.gohtml"))
-		var buf bytes.Buffer
-		graphW := bufio.NewWriter(&buf)
-		check(cytoGraph.WriteJson(graphW), "could not write graph to buffer: %v")
-		check(graphW.Flush(), "could not flush graph buffer: %v")
+	if af.roots != "" {
+		aProg.Roots = strings.Split(af.roots, ",")
+	}
+	aProg.NoInits = af.noInits
+	aProg.PolymorphicTop = af.polymorphic
+	aProg.Implementations = af.implements
+	aProg.Panics = af.panics
+	if af.featureFlags != "" {
+		aProg.FeatureFlags = strings.Split(af.featureFlags, ",")
+	}
+	aProg.Pointer.Reflection = af.ptrReflect
+	if af.ptrQuery != "" {
+		aProg.Pointer.Queries = strings.Split(af.ptrQuery, ",")
+		aProg.Pointer.QueryOut = os.Stderr
+	}
+	if af.ptrLog != "" {
+		logFile, err := os.Create(af.ptrLog)
+		check(err, "could not create pointer log file: %v")
+		defer logFile.Close()
+		logW := bufio.NewWriter(logFile)
+		defer logW.Flush()
+		aProg.Pointer.Log = logW
+	}
 
-		var pkgListText bytes.Buffer
-		for _, p := range aProg.Mains {
-			pkgListText.WriteString(p.Pkg.Path())
-			pkgListText.WriteString("\n")
+	used := mode
+	var callGraph *callgraph.Graph
+	var failure error
+	if af.budget != 0 {
+		callGraph, used, failure = mode.ComputeBudgetedCallgraph(aProg, af.budget)
+	} else {
+		callGraph, failure = mode.ComputeFallbackCallgraph(aProg, af.timeout)
+		if failure != nil {
+			used = analysis.StaticAnalysis
 		}
+	}
+	if callGraph == nil {
+		check(failure, "could not compute call graph: %v")
+	}
+	return aProg, used, callGraph, failure
+}
 
-		check(
-			tmpl.Execute(w,
-				WebData{
-					Packages:  pkgListText.String(),
-					GraphJSON: template.JS(buf.String()),
-				}),
-			"could not write index.html to output: %v")
+// renderGraph loads the call graph, and the reports of the analysis, into the cyto graph.
+func renderGraph(cytoGraph *render.CytoGraph, mode analysis.AnalysisMode, aProg *analysis.ProgramAnalysis, callGraph *callgraph.Graph, opts *render.RenderOptions) {
+	opts.Modules = aProg.Modules
+	opts.Entrypoints = aProg.EntrypointLabels()
+	opts.Callbacks = aProg.CallbackLabels()
+	opts.Tests = aProg.TestKinds()
+	opts.Constraints = aProg.Constraints
+	opts.PlatformBranches = aProg.PlatformBranches
+	opts.TestDoubleFiles = aProg.TestDoubleFiles
+	opts.Platform = ""
+	opts.GatedFuncs, opts.GuardedCalls = nil, nil
+	if len(aProg.FeatureFlags) > 0 {
+		gates, err := aProg.FlagGates(callGraph, aProg.FeatureFlags)
+		check(err, "could not find feature-flag gated code: %v")
+		opts.GatedFuncs, opts.GuardedCalls = analysis.FlagLabels(gates)
+		cytoGraph.AddReport("feature-flags", gates)
 	}
-	outPath := *outFlag
-	web := *webFlag
-	if outPath == "" {
-		if web {
-			writeAsHtml(os.Stdout)
-		} else {
-			check(cytoGraph.WriteJson(os.Stdout), "could not write graph JSON to std out: %v")
+	opts.Spawns = analysis.SpawnCounts(callGraph)
+	opts.Reflective = analysis.ReflectiveFuncs(callGraph)
+	opts.Panicking, opts.Recovering = analysis.PanicFuncs(callGraph)
+	opts.EscapingPanics = nil
+	if aProg.Panics {
+		escaping, err := analysis.FindEscapingPanics(aProg, callGraph)
+		check(err, "could not find escaping panics: %v")
+		opts.EscapingPanics = analysis.EscapingPanicFuncs(escaping)
+		cytoGraph.AddReport("panics", escaping)
+	}
+	if aProg.BuildConfig != nil {
+		opts.Platform = aProg.BuildConfig.String()
+		if n := len(cytoGraph.Meta.Platforms); n == 0 || cytoGraph.Meta.Platforms[n-1] != opts.Platform {
+			cytoGraph.Meta.Platforms = append(cytoGraph.Meta.Platforms, opts.Platform)
 		}
-	} else {
-		f, err := os.Create(outPath)
-		check(err, "could not create file: %v")
-		defer f.Close()
-		w := bufio.NewWriter(f)
-
-		if web {
-			writeAsHtml(w)
-		} else {
-			check(cytoGraph.WriteJson(f), "could not write graph JSON to file: %v")
+	}
+
+	if err := cytoGraph.LoadCallGraph(callGraph, opts); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: could not load all of the call graph, output is partial: %v\n", err)
+		cytoGraph.Meta.Partial = true
+		cytoGraph.Meta.PartialReason = err.Error()
+	}
+	if aProg.Implementations {
+		cytoGraph.AddImplementations(analysis.FindImplementations(aProg))
+	}
+	known := make(map[string]bool)
+	for _, p := range cytoGraph.Meta.Packages {
+		known[p] = true
+	}
+	for _, p := range aProg.Mains {
+		if !known[p.Pkg.Path()] {
+			cytoGraph.Meta.Packages = append(cytoGraph.Meta.Packages, p.Pkg.Path())
 		}
-		check(w.Flush(), "could not flush output to file: %v")
 	}
+	cytoGraph.Meta.Mode = mode.String()
+	for i := range aProg.Fallbacks {
+		f := &aProg.Fallbacks[i]
+		_, _ = fmt.Fprintf(os.Stderr, "warning: abandoned analysis %s\n", f)
+		cytoGraph.Meta.Fallbacks = append(cytoGraph.Meta.Fallbacks, f.String())
+	}
+	if aProg.Reachability != nil {
+		cytoGraph.AddReport("reachable", aProg.Reachability)
+	}
+	if aProg.PolymorphicTop > 0 {
+		cytoGraph.AddReport("polymorphism", analysis.FindPolymorphism(aProg, callGraph, aProg.PolymorphicTop))
+	}
+	if len(aProg.Unresolved) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: %d dynamic call sites are not resolved by static analysis, see the \"unresolved\" report\n", len(aProg.Unresolved))
+		cytoGraph.AddReport("unresolved", aProg.Unresolved)
+	}
+}
+
+// writeSupernodes summarizes the graph, and writes the detail graph of each supernode, as JSON,
+// to a file named after the output file, e.g. graph.3.json for supernode 3 of graph.html.
+func writeSupernodes(cytoGraph *render.CytoGraph, of *outputFlags) {
+	if of.out == "" {
+		_, _ = fmt.Fprintln(os.Stderr, "-summarize requires -out, to write the detail files next to")
+		os.Exit(2)
+	}
+	base := strings.TrimSuffix(of.out, filepath.Ext(of.out))
+	for i, s := range cytoGraph.Summarize(of.summarize) {
+		detailPath := fmt.Sprintf("%s.%d.json", base, i)
+		s.Node.Data.Detail = filepath.Base(detailPath)
+		output(detailPath, func(w io.Writer) {
+			writeJson(w, s.Detail, of.pretty)
+		})
+	}
+}
+
+// writeWeb writes an index.html with the graph data embedded.
+func writeWeb(w io.Writer, cytoGraph *render.CytoGraph) error {
+	tmpl, err := template.ParseFiles("index.gohtml")
+	if err != nil {
+		return err
+	}
+	return writeWebPage(w, tmpl, cytoGraph)
+}
+
+// writeWebPage writes the graph embedded in the parsed index.gohtml template.
+func writeWebPage(w io.Writer, tmpl *template.Template, cytoGraph *render.CytoGraph) error {
+	var buf bytes.Buffer
+	if err := cytoGraph.WriteJson(&buf); err != nil {
+		return err
+	}
+
+	var pkgListText bytes.Buffer
+	for _, p := range cytoGraph.Meta.Packages {
+		pkgListText.WriteString(p)
+		pkgListText.WriteString("\n")
+	}
+
+	return tmpl.Execute(w,
+		WebData{
+			Packages:  pkgListText.String(),
+			GraphJSON: template.JS(buf.String()),
+			Outline:   cytoGraph.Outline(),
+		})
+}
+
+// writeGraph writes the graph as JSON, or embedded in an index.html
+func writeGraph(cytoGraph *render.CytoGraph, of *outputFlags) {
+	cytoGraph.SetSortOrder(of.sortOrder())
+	if of.srcURL != "" {
+		cytoGraph.LinkSources(newSourceLinker(of.srcURL).link)
+	}
+	if of.redact {
+		cytoGraph.Redact()
+	}
+	if of.summarize > 0 {
+		writeSupernodes(cytoGraph, of)
+	}
+	switch of.outputFormat() {
+	case "web":
+		output(of.out, func(w io.Writer) {
+			check(writeWeb(w, cytoGraph), "could not write index.html to output: %v")
+		})
+	case "widget":
+		writeWidget(cytoGraph, of)
+	default:
+		output(of.out, func(w io.Writer) {
+			writeJson(w, cytoGraph, of.pretty)
+		})
+	}
+}
+
+// writeJson writes the graph JSON, pretty printed if requested.
+func writeJson(w io.Writer, cytoGraph *render.CytoGraph, pretty bool) {
+	if pretty {
+		check(cytoGraph.WritePrettyJson(w), "could not write graph JSON to output: %v")
+	} else {
+		check(cytoGraph.WriteJson(w), "could not write graph JSON to output: %v")
+	}
+}
+
+// writeWidget writes the widget HTML to the output file, and the graph JSON it loads next to it,
+// e.g. graph.json for graph.html.
+func writeWidget(cytoGraph *render.CytoGraph, of *outputFlags) {
+	if of.out == "" {
+		_, _ = fmt.Fprintln(os.Stderr, "-format widget requires -out, to write the graph JSON next to")
+		os.Exit(2)
+	}
+	graphPath := strings.TrimSuffix(of.out, filepath.Ext(of.out)) + ".json"
+	if graphPath == of.out {
+		graphPath = of.out + ".json"
+	}
+	output(graphPath, func(w io.Writer) {
+		writeJson(w, cytoGraph, of.pretty)
+	})
+	tmpl := template.Must(template.ParseFiles("widget.gohtml"))
+	output(of.out, func(w io.Writer) {
+		check(tmpl.Execute(w, WidgetData{GraphURL: filepath.Base(graphPath)}), "could not write widget to output: %v")
+	})
 }
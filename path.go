@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/protolambda/gocyto/render"
+)
+
+const pathUsage = `
+Compute the call graph, and render only the call paths from one function to another,
+e.g. to find out how main reaches os/exec.Command.
+
+Usage:
+
+gocyto path [options...] <from function> <to function> <package path(s)>
+
+Functions are fully qualified, or named with just the package name, e.g. main.main or (*exec.Cmd).Run
+With -max-length, the paths up to that number of calls are enumerated, and listed with -text.
+
+Options:
+
+`
+
+func pathCmd(args []string) {
+	flags := flag.NewFlagSet("path", flag.ExitOnError)
+	var af analysisFlags
+	af.register(flags)
+	var pf profileFlags
+	pf.register(flags)
+	var of outputFlags
+	of.register(flags)
+	maxLenFlag := flags.Int("max-length", 0, "Enumerate the paths of at most this number of calls, that call no function twice. All paths if 0")
+	maxPathsFlag := flags.Int("max-paths", 100, "Maximum number of paths to enumerate with -max-length. No limit if 0")
+	textFlag := flags.Bool("text", false, "List the enumerated paths as text instead of the graph")
+	sourceFlag := flags.Bool("source", false, "With -text, include the position and source line of each call")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, pathUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() < 3 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	stopProfiles := pf.start()
+	defer stopProfiles()
+
+	// paths may go through any function, nothing is filtered out.
+	opts := &render.RenderOptions{
+		IncludeGoRoot:     true,
+		IncludeUnexported: true,
+	}
+	cytoGraph := analyzeGraph(flags, &af, flags.Args()[2:], opts)
+	// the paths are enumerated in the sort order
+	cytoGraph.SetSortOrder(of.sortOrder())
+	if *maxLenFlag == 0 {
+		if !cytoGraph.KeepPaths(flags.Arg(0), flags.Arg(1)) {
+			_, _ = fmt.Fprintf(os.Stderr, "no call path found from %s to %s\n", flags.Arg(0), flags.Arg(1))
+			os.Exit(1)
+		}
+		writeGraph(cytoGraph, &of)
+		return
+	}
+
+	paths := cytoGraph.EnumeratePaths(flags.Arg(0), flags.Arg(1), *maxLenFlag, *maxPathsFlag)
+	if len(paths) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "no call path of at most %d calls found from %s to %s\n", *maxLenFlag, flags.Arg(0), flags.Arg(1))
+		os.Exit(1)
+	}
+	if *maxPathsFlag != 0 && len(paths) >= *maxPathsFlag {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: stopped after %d paths, there may be more\n", len(paths))
+	}
+	if *textFlag {
+		var src *render.SourceLines
+		if *sourceFlag {
+			src = render.NewSourceLines()
+		}
+		output(of.out, func(w io.Writer) {
+			check(cytoGraph.WritePathsText(w, paths, src), "could not write paths: %v")
+		})
+		return
+	}
+	writeGraph(cytoGraph, &of)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/protolambda/gocyto/render"
+)
+
+const analyzeUsage = `
+Compute the call graph, and write it to an intermediate graph JSON file, with all nodes included.
+Render it, with different options as many times as needed, with "gocyto render".
+
+Usage:
+
+gocyto analyze [options...] <package path(s)>
+
+Options:
+
+`
+
+func analyzeCmd(args []string) {
+	flags := flag.NewFlagSet("analyze", flag.ExitOnError)
+	var af analysisFlags
+	af.register(flags)
+	var pf profileFlags
+	pf.register(flags)
+	outFlag := flags.String("out", "", "Output file, if none is specified, output to std out")
+	prettyFlag := flags.Bool("pretty", false, "Indent the graph JSON, and sort the keys of all objects, e.g. to review changes to a checked in graph")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, analyzeUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	stopProfiles := pf.start()
+	defer stopProfiles()
+
+	// include everything, the render stage filters.
+	opts := &render.RenderOptions{
+		IncludeGoRoot:     true,
+		IncludeUnexported: true,
+	}
+	cytoGraph := analyzeGraph(flags, &af, flags.Args(), opts)
+	output(*outFlag, func(w io.Writer) {
+		writeJson(w, cytoGraph, *prettyFlag)
+	})
+}
+
+const renderUsage = `
+Render a graph JSON file, as written by "gocyto analyze", with the given options.
+
+Usage:
+
+gocyto render [options...] <graph.json>
+
+Options:
+
+`
+
+func renderCmd(args []string) {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	var rf renderFlags
+	rf.register(flags)
+	var of outputFlags
+	of.register(flags)
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, renderUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cytoGraph, err := readGraphFile(flags.Arg(0))
+	check(err, "could not read graph: %v")
+	opts := rf.options()
+	cytoGraph.Filter(opts)
+	checkFocus(cytoGraph, opts)
+	writeGraph(cytoGraph, &of)
+}
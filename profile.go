@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profileFlags enable profiling of gocyto itself, to investigate its performance.
+type profileFlags struct {
+	cpu   string
+	mem   string
+	trace string
+}
+
+func (f *profileFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.cpu, "cpuprofile", "", "Write a pprof CPU profile of gocyto to this file")
+	fs.StringVar(&f.mem, "memprofile", "", "Write a pprof heap profile of gocyto to this file, when done")
+	fs.StringVar(&f.trace, "trace", "", "Write an execution trace of gocyto to this file")
+}
+
+// start starts the enabled profiles, and returns a function that stops them and writes the results.
+func (f *profileFlags) start() (stop func()) {
+	var stops []func()
+	if f.cpu != "" {
+		out, err := os.Create(f.cpu)
+		check(err, "could not create CPU profile: %v")
+		check(pprof.StartCPUProfile(out), "could not start CPU profile: %v")
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			check(out.Close(), "could not write CPU profile: %v")
+		})
+	}
+	if f.trace != "" {
+		out, err := os.Create(f.trace)
+		check(err, "could not create trace: %v")
+		check(trace.Start(out), "could not start trace: %v")
+		stops = append(stops, func() {
+			trace.Stop()
+			check(out.Close(), "could not write trace: %v")
+		})
+	}
+	if f.mem != "" {
+		stops = append(stops, func() {
+			out, err := os.Create(f.mem)
+			check(err, "could not create heap profile: %v")
+			// get up-to-date statistics
+			runtime.GC()
+			check(pprof.WriteHeapProfile(out), "could not write heap profile: %v")
+			check(out.Close(), "could not write heap profile: %v")
+		})
+	}
+	return func() {
+		for _, s := range stops {
+			s()
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/protolambda/gocyto/render"
+)
+
+const rankUsage = `
+Compute the call graph, and list the most important functions by PageRank, with the calls as links:
+functions called by many functions, or by other important functions, rank higher.
+
+Usage:
+
+gocyto rank [options...] <package path(s)>
+
+Options:
+
+`
+
+func rankCmd(args []string) {
+	flags := flag.NewFlagSet("rank", flag.ExitOnError)
+	var af analysisFlags
+	af.register(flags)
+	var rf renderFlags
+	rf.register(flags)
+	var pf profileFlags
+	pf.register(flags)
+	topFlag := flags.Int("top", 20, "Number of functions to list")
+	jsonFlag := flags.Bool("json", false, "Output the ranked functions as JSON instead of text")
+	outFlag := flags.String("out", "", "Output file, if none is specified, output to std out")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, rankUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	stopProfiles := pf.start()
+	defer stopProfiles()
+
+	opts := rf.options()
+	opts.Rank = true
+	cytoGraph := analyzeGraph(flags, &af, flags.Args(), opts)
+	ranked := cytoGraph.TopRanked(*topFlag)
+	output(*outFlag, func(w io.Writer) {
+		if *jsonFlag {
+			check(json.NewEncoder(w).Encode(ranked), "could not write ranking JSON: %v")
+		} else {
+			check(render.WriteRankedText(w, ranked), "could not write ranking: %v")
+		}
+	})
+}
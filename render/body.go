@@ -0,0 +1,161 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// instrKind names the kind of instruction, e.g. "call" or "if", used as class of its node.
+func instrKind(instr ssa.Instruction) string {
+	return strings.ToLower(strings.TrimPrefix(fmt.Sprintf("%T", instr), "*ssa."))
+}
+
+// instrLabel shows the instruction as in the SSA dump, with the name of the value it defines, if any.
+func instrLabel(instr ssa.Instruction) string {
+	if v, ok := instr.(ssa.Value); ok {
+		return v.Name() + " = " + instr.String()
+	}
+	return instr.String()
+}
+
+// visibleInstrs returns the instructions of the block to show, debug references are left out.
+func visibleInstrs(b *ssa.BasicBlock) []ssa.Instruction {
+	var out []ssa.Instruction
+	for _, instr := range b.Instrs {
+		if _, ok := instr.(*ssa.DebugRef); !ok {
+			out = append(out, instr)
+		}
+	}
+	return out
+}
+
+// LoadFunctionBody adds the control flow of the function to the cyto graph: a compound node of the function,
+// with a compound node per basic block, with the instructions in it. Instructions are connected in order,
+// and the last instruction of each block to the first instruction of its successors.
+// Calls, go statements and defers are connected to the function or interface method they call, if known.
+func (cg *CytoGraph) LoadFunctionBody(fn *ssa.Function) {
+	_, fnID := cg.GetID("body ~ "+fn.String(), true)
+	fnNode := newCytoNode(NodeData{Id: fnID, Label: fn.String(), Name: fn.String()})
+	if fn.Signature != nil {
+		fnNode.Data.Signature = fn.Signature.String()
+		fnNode.Data.Color = signatureToColorHex(fn.Signature)
+	}
+	if pos := fn.Pos(); pos.IsValid() {
+		position := fn.Prog.Fset.Position(pos)
+		fnNode.Data.File = position.Filename
+		fnNode.Data.Line = position.Line
+	}
+	fnNode.Classes = append(fnNode.Classes, "function")
+	cg.Nodes[fnID] = fnNode
+
+	type callNode struct {
+		id   CytoID
+		site ssa.CallInstruction
+	}
+	var calls []callNode
+	firstIDs := make(map[*ssa.BasicBlock]CytoID)
+	lastIDs := make(map[*ssa.BasicBlock]CytoID)
+	for _, b := range fn.Blocks {
+		_, blockID := cg.GetID(fmt.Sprintf("block ~ %s ~ %d", fn.String(), b.Index), true)
+		label := fmt.Sprintf("%d", b.Index)
+		if b.Comment != "" {
+			label += ": " + b.Comment
+		}
+		blockNode := newCytoNode(NodeData{Id: blockID, Label: label, Parent: fnID})
+		blockNode.Data.Color = integersToColor(stringToIntHash(b.Comment)).Hex()
+		blockNode.Classes = append(blockNode.Classes, "block")
+		if b == fn.Recover {
+			blockNode.Classes = append(blockNode.Classes, "recover")
+		}
+		cg.Nodes[blockID] = blockNode
+
+		var prev CytoID
+		for i, instr := range visibleInstrs(b) {
+			_, id := cg.GetID(fmt.Sprintf("instr ~ %s ~ %d ~ %d", fn.String(), b.Index, i), true)
+			label := instrLabel(instr)
+			instrNode := newCytoNode(NodeData{Id: id, Label: label, Description: &label, Parent: blockID})
+			instrNode.Data.Color = integersToColor(stringToIntHash(instrKind(instr))).Hex()
+			if pos := instr.Pos(); pos.IsValid() {
+				instrNode.Data.Line = fn.Prog.Fset.Position(pos).Line
+			}
+			instrNode.Classes = append(instrNode.Classes, "instruction", instrKind(instr))
+			cg.Nodes[id] = instrNode
+			if site, ok := instr.(ssa.CallInstruction); ok {
+				calls = append(calls, callNode{id: id, site: site})
+			}
+			if prev == "" {
+				firstIDs[b] = id
+			} else {
+				cg.addBodyEdge(prev, id, "next")
+			}
+			prev = id
+		}
+		lastIDs[b] = prev
+	}
+
+	for _, b := range fn.Blocks {
+		_, isIf := b.Instrs[len(b.Instrs)-1].(*ssa.If)
+		for i, succ := range b.Succs {
+			classes := []string{"branch"}
+			if isIf {
+				if i == 0 {
+					classes = append(classes, "true")
+				} else {
+					classes = append(classes, "false")
+				}
+			}
+			cg.addBodyEdge(lastIDs[b], firstIDs[succ], classes...)
+		}
+	}
+
+	for _, c := range calls {
+		classes := []string{"call"}
+		switch c.site.(type) {
+		case *ssa.Go:
+			classes = append(classes, "concurrent")
+		case *ssa.Defer:
+			classes = append(classes, "deferred")
+		}
+		if calleeID := cg.bodyCallee(c.site.Common()); calleeID != "" {
+			cg.addBodyEdge(c.id, calleeID, classes...)
+		}
+	}
+}
+
+// bodyCallee returns the node of the function or interface method the call calls, or "" if it is dynamic.
+func (cg *CytoGraph) bodyCallee(common *ssa.CallCommon) CytoID {
+	var name string
+	var classes []string
+	if common.IsInvoke() {
+		name = common.Value.Type().String() + "." + common.Method.Name()
+		classes = append(classes, "callee", "invoke")
+	} else if callee := common.StaticCallee(); callee != nil {
+		name = callee.String()
+		classes = append(classes, "callee", "static")
+	} else if b, ok := common.Value.(*ssa.Builtin); ok {
+		name = b.Name()
+		classes = append(classes, "callee", "builtin")
+	} else {
+		return ""
+	}
+	isNew, id := cg.GetID("callee ~ "+name, true)
+	if isNew {
+		n := newCytoNode(NodeData{Id: id, Label: name, Name: name})
+		n.Data.Color = integersToColor(stringToIntHash(name)).Hex()
+		n.Classes = append(n.Classes, classes...)
+		cg.Nodes[id] = n
+	}
+	return id
+}
+
+func (cg *CytoGraph) addBodyEdge(source CytoID, target CytoID, classes ...string) {
+	if source == "" || target == "" {
+		return
+	}
+	_, id := cg.GetID(fmt.Sprintf("flow ~ %s -> %s ~ %s", source, target, strings.Join(classes, " ")), false)
+	e := newCytoEdge(EdgeData{Id: id, Source: source, Target: target})
+	e.Classes = append(e.Classes, classes...)
+	cg.Edges[id] = e
+}
@@ -0,0 +1,182 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// KeepCallers removes the edges that are not on a call path to the functions with the name,
+// within depth calls (no limit if 0), and then prunes the graph. The functions are marked with the root class.
+// Functions are named fully qualified, or with just the package name.
+// Returns false if there is no such function, or it has no callers.
+func (cg *CytoGraph) KeepCallers(name string, depth int) bool {
+	_, in := cg.adjacency()
+	return cg.keepWithin(name, depth, in, func(e *CytoEdge) CytoID { return e.Data.Target })
+}
+
+// KeepCallees removes the edges that are not on a call path from the functions with the name,
+// within depth calls (no limit if 0), and then prunes the graph. The functions are marked with the root class.
+// Functions are named fully qualified, or with just the package name.
+// Returns false if there is no such function, or it calls nothing.
+func (cg *CytoGraph) KeepCallees(name string, depth int) bool {
+	out, _ := cg.adjacency()
+	return cg.keepWithin(name, depth, out, func(e *CytoEdge) CytoID { return e.Data.Source })
+}
+
+// keepWithin keeps the edges of which the near end is within depth hops of the named functions,
+// following the adjacency, and then prunes the graph.
+func (cg *CytoGraph) keepWithin(name string, depth int, adj map[CytoID][]CytoID, near func(e *CytoEdge) CytoID) bool {
+	start := cg.nodesNamed(name)
+	dist := hopDistances(start, adj, depth-1)
+	for id, e := range cg.Edges {
+		if d, ok := dist[near(e)]; !ok || (depth > 0 && d >= depth) {
+			delete(cg.Edges, id)
+		}
+	}
+	cg.Prune()
+	for _, id := range start {
+		if n, ok := cg.Nodes[id]; ok {
+			n.Classes = append(n.Classes, "root")
+		}
+	}
+	return len(cg.Edges) > 0
+}
+
+// WriteCallersText writes the callers of the functions with the name as an indented tree, in the graph as left
+// by KeepCallers, before Invert. Functions that are already written are marked with "(see above)" instead of repeated.
+// Callers are written by name, or in the sort order of the graph if one is set.
+// If src is not nil, each caller is followed by the position and source line of its call.
+func (cg *CytoGraph) WriteCallersText(w io.Writer, name string, src *SourceLines) error {
+	_, in := cg.adjacency()
+	order := cg.listOrder()
+	var sites map[[2]CytoID]*CytoEdge
+	if src != nil {
+		sites = cg.firstCallSites()
+	}
+	for _, callers := range in {
+		sort.Slice(callers, func(i, j int) bool { return order[callers[i]] < order[callers[j]] })
+	}
+	written := make(map[CytoID]bool)
+	var write func(id CytoID, callee CytoID, depth int) error
+	write = func(id CytoID, callee CytoID, depth int) error {
+		indent := strings.Repeat("  ", depth)
+		line := indent + cg.Nodes[id].Data.Name
+		if depth > 0 {
+			line = indent + "<- " + cg.Nodes[id].Data.Name
+		}
+		if written[id] {
+			line += " (see above)"
+		}
+		if src != nil && depth > 0 {
+			if context := sourceContext(src, sites[[2]CytoID{id, callee}]); context != "" {
+				line += "  " + context
+			}
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		if written[id] {
+			return nil
+		}
+		written[id] = true
+		seen := make(map[CytoID]bool)
+		for _, caller := range in[id] {
+			// multiple calls from the same caller are written once
+			if seen[caller] {
+				continue
+			}
+			seen[caller] = true
+			if err := write(caller, id, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start := cg.nodesNamed(name)
+	sort.Slice(start, func(i, j int) bool { return order[start[i]] < order[start[j]] })
+	for _, id := range start {
+		if _, ok := cg.Nodes[id]; !ok {
+			continue
+		}
+		if err := write(id, "", 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Invert swaps the source and target of all edges, so arrows point from callees to their callers.
+// The edges are marked with the inverted class.
+func (cg *CytoGraph) Invert() {
+	for _, e := range cg.Edges {
+		e.Data.Source, e.Data.Target = e.Data.Target, e.Data.Source
+		e.Classes = append(e.Classes, "inverted")
+	}
+}
+
+// LinkFuncs adds an edge between the nodes of the functions with the fully qualified names, with the classes,
+// e.g. to connect the call graphs of multiple programs. Returns false if either function is not in the graph.
+func (cg *CytoGraph) LinkFuncs(caller string, callee string, classes ...string) bool {
+	callerID, ok := cg.idMap["func ~ "+caller]
+	if !ok {
+		return false
+	}
+	calleeID, ok := cg.idMap["func ~ "+callee]
+	if !ok {
+		return false
+	}
+	_, id := cg.GetID(fmt.Sprintf("link ~ %s -> %s", caller, callee), false)
+	e := newCytoEdge(EdgeData{Id: id, Source: callerID, Target: calleeID})
+	e.Classes = append(e.Classes, classes...)
+	cg.Edges[id] = e
+	return true
+}
+
+// PackageFuncs are the functions of a package in the graph.
+type PackageFuncs struct {
+	Package string   `json:"package"`
+	Count   int      `json:"count"`
+	Funcs   []string `json:"funcs"`
+}
+
+// FuncsByPackage aggregates the function nodes, except the root nodes, per package, sorted by package path.
+// The functions of a package are sorted by name, or in the sort order of the graph if one is set.
+func (cg *CytoGraph) FuncsByPackage() []PackageFuncs {
+	var nodes []*CytoNode
+	for _, n := range cg.Nodes {
+		if n.Data.Name != "" && !n.HasClass("root") {
+			nodes = append(nodes, n)
+		}
+	}
+	cg.sortListed(nodes)
+	byPkg := make(map[string][]string)
+	for _, n := range nodes {
+		pkg := cg.nodePackage(n)
+		byPkg[pkg] = append(byPkg[pkg], n.Data.Name)
+	}
+	out := make([]PackageFuncs, 0, len(byPkg))
+	for pkg, funcs := range byPkg {
+		out = append(out, PackageFuncs{Package: pkg, Count: len(funcs), Funcs: funcs})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Package < out[j].Package
+	})
+	return out
+}
+
+// WritePackageFuncsText writes the function count of each package, followed by its functions.
+func WritePackageFuncsText(w io.Writer, pkgs []PackageFuncs) error {
+	for _, p := range pkgs {
+		if _, err := fmt.Fprintf(w, "%s: %d functions\n", p.Package, p.Count); err != nil {
+			return err
+		}
+		for _, f := range p.Funcs {
+			if _, err := fmt.Fprintf(w, "  %s\n", f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
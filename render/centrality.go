@@ -0,0 +1,177 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// betweenness sets the betweenness centrality of each function node: the fraction of the shortest call paths
+// between other functions that go through it, with Brandes' algorithm.
+// If samples is positive and less than the number of nodes, the shortest paths from only that many source nodes,
+// spread evenly over the nodes, are counted, and the result is extrapolated.
+func (cg *CytoGraph) betweenness(samples int) {
+	out, in := cg.adjacency()
+	ids := make([]CytoID, 0, len(out)+len(in))
+	for id := range out {
+		ids = append(ids, id)
+	}
+	for id := range in {
+		if _, ok := out[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	n := len(ids)
+	sources := ids
+	if samples > 0 && samples < n {
+		sources = make([]CytoID, 0, samples)
+		for i := 0; i < samples; i++ {
+			sources = append(sources, ids[i*n/samples])
+		}
+	}
+
+	centrality := make(map[CytoID]float64)
+	for _, s := range sources {
+		// breadth-first search for the shortest paths from s, counting them
+		var stack []CytoID
+		preds := make(map[CytoID][]CytoID)
+		paths := map[CytoID]float64{s: 1}
+		dist := map[CytoID]int{s: 0}
+		queue := []CytoID{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for _, w := range out[v] {
+				if _, ok := dist[w]; !ok {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					paths[w] += paths[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+		// accumulate the dependencies of s on the nodes, furthest first
+		dependency := make(map[CytoID]float64)
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range preds[w] {
+				dependency[v] += paths[v] / paths[w] * (1 + dependency[w])
+			}
+			if w != s {
+				centrality[w] += dependency[w]
+			}
+		}
+	}
+
+	// normalize by the number of pairs of other nodes, and extrapolate the samples
+	scale := 0.0
+	if n > 2 && len(sources) > 0 {
+		scale = float64(n) / float64(len(sources)) / float64((n-1)*(n-2))
+	}
+	for _, id := range ids {
+		if node, ok := cg.Nodes[id]; ok {
+			node.Data.Betweenness = centrality[id] * scale
+		}
+	}
+}
+
+// pageRank sets the PageRank of each function node, with the calls as links: functions called by many,
+// or by important functions, rank higher. The ranks sum up to 1.
+func (cg *CytoGraph) pageRank() {
+	const (
+		damping    = 0.85
+		iterations = 100
+		tolerance  = 1e-9
+	)
+	out, in := cg.adjacency()
+	ids := make([]CytoID, 0, len(out)+len(in))
+	for id := range out {
+		ids = append(ids, id)
+	}
+	for id := range in {
+		if _, ok := out[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	// sums of the ranks are added up in ID order, for the same rounding every time
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	n := float64(len(ids))
+	rank := make(map[CytoID]float64)
+	for _, id := range ids {
+		rank[id] = 1 / n
+	}
+	for i := 0; i < iterations; i++ {
+		// functions that call nothing spread their rank over all functions
+		dangling := 0.0
+		for _, id := range ids {
+			if len(out[id]) == 0 {
+				dangling += rank[id]
+			}
+		}
+		next := make(map[CytoID]float64)
+		for _, id := range ids {
+			next[id] = (1-damping)/n + damping*dangling/n
+		}
+		for _, id := range ids {
+			for _, callee := range out[id] {
+				next[callee] += damping * rank[id] / float64(len(out[id]))
+			}
+		}
+		diff := 0.0
+		for _, id := range ids {
+			diff += math.Abs(next[id] - rank[id])
+		}
+		rank = next
+		if diff < tolerance {
+			break
+		}
+	}
+	for _, id := range ids {
+		if node, ok := cg.Nodes[id]; ok {
+			node.Data.Rank = rank[id]
+		}
+	}
+}
+
+// RankedFunc is a function with its PageRank.
+type RankedFunc struct {
+	Func string  `json:"func"`
+	File string  `json:"file,omitempty"`
+	Line int     `json:"line,omitempty"`
+	Rank float64 `json:"rank"`
+}
+
+// TopRanked returns the top function nodes with the highest rank, highest first, as computed with the Rank option.
+func (cg *CytoGraph) TopRanked(top int) []RankedFunc {
+	var out []RankedFunc
+	for _, n := range cg.Nodes {
+		if n.Data.Name != "" && n.Data.Rank > 0 {
+			out = append(out, RankedFunc{Func: n.Data.Name, File: n.Data.File, Line: n.Data.Line, Rank: n.Data.Rank})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Rank != out[j].Rank {
+			return out[i].Rank > out[j].Rank
+		}
+		return out[i].Func < out[j].Func
+	})
+	if len(out) > top {
+		out = out[:top]
+	}
+	return out
+}
+
+// WriteRankedText writes each ranked function on a line, with its rank and position.
+func WriteRankedText(w io.Writer, funcs []RankedFunc) error {
+	for _, f := range funcs {
+		if _, err := fmt.Fprintf(w, "%.6f %s %s:%d\n", f.Rank, f.Func, f.File, f.Line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
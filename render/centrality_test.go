@@ -0,0 +1,66 @@
+package render
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPageRank(t *testing.T) {
+	cases := []struct {
+		name  string
+		calls []string
+		ranks map[string]float64
+	}{
+		{"cycle", []string{"a->b", "b->c", "c->a"}, map[string]float64{"a": 1.0 / 3, "b": 1.0 / 3, "c": 1.0 / 3}},
+		// b calls nothing, its rank is spread over both functions: b = 0.075 + 0.425b + 0.85a, with a + b = 1
+		{"dangling", []string{"a->b"}, map[string]float64{"a": 0.5 / 1.425, "b": 0.925 / 1.425}},
+		// a = 0.0375 + 0.2125d, with 3a + d = 1
+		{"star", []string{"a->d", "b->d", "c->d"},
+			map[string]float64{"a": 0.25 / 1.6375, "b": 0.25 / 1.6375, "c": 0.25 / 1.6375, "d": 1 - 0.75/1.6375}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cg := testGraph(c.calls...)
+			cg.pageRank()
+			sum := 0.0
+			for name, expected := range c.ranks {
+				got := testFunc(cg, name).Data.Rank
+				sum += got
+				if math.Abs(got-expected) > 1e-4 {
+					t.Errorf("got rank %f for %s, expected %f", got, name, expected)
+				}
+			}
+			if math.Abs(sum-1) > 1e-9 {
+				t.Errorf("ranks sum up to %f, expected 1", sum)
+			}
+		})
+	}
+}
+
+func TestBetweenness(t *testing.T) {
+	cases := []struct {
+		name        string
+		calls       []string
+		samples     int
+		betweenness map[string]float64
+	}{
+		// the only path between other functions, a->c, goes through b: 1 of the 2 ordered pairs of other functions
+		{"chain", []string{"a->b", "b->c"}, 0, map[string]float64{"a": 0, "b": 0.5, "c": 0}},
+		// b and c each carry half of the shortest paths from a to d, of the 6 ordered pairs of other functions
+		{"diamond", []string{"a->b", "a->c", "b->d", "c->d"}, 0, map[string]float64{"a": 0, "b": 1.0 / 12, "c": 1.0 / 12, "d": 0}},
+		{"shortcut", []string{"a->b", "b->c", "a->c"}, 0, map[string]float64{"a": 0, "b": 0, "c": 0}},
+		// a single sample, from a, is extrapolated to all 3 sources
+		{"sampled", []string{"a->b", "b->c"}, 1, map[string]float64{"a": 0, "b": 1.5, "c": 0}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cg := testGraph(c.calls...)
+			cg.betweenness(c.samples)
+			for name, expected := range c.betweenness {
+				if got := testFunc(cg, name).Data.Betweenness; math.Abs(got-expected) > 1e-9 {
+					t.Errorf("got betweenness %f for %s, expected %f", got, name, expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package render
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// cgoPrefix is the prefix cgo names the Go stubs of C functions with, e.g. _Cfunc_puts for C.puts.
+const cgoPrefix = "_Cfunc_"
+
+// cFuncName returns the name of the C function the function is the cgo stub of, e.g. "C.puts".
+func cFuncName(fn *ssa.Function) (string, bool) {
+	if fn.Parent() != nil || !strings.HasPrefix(fn.Name(), cgoPrefix) {
+		return "", false
+	}
+	return "C." + strings.TrimPrefix(fn.Name(), cgoPrefix), true
+}
+
+// callsC tells if the function calls C functions through cgo.
+func callsC(fn *ssa.Function) bool {
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			site, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			if callee := site.Common().StaticCallee(); callee != nil {
+				if _, ok := cFuncName(callee); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package render
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// channel identifies a channel by the make instruction that creates it, if the channel operations of a function
+// can be traced back to it, or else by its type, which joins all such channels of the same type.
+type channel struct {
+	key   string
+	label string
+	// make is the instruction that creates the channel, nil if the channel is identified by its type
+	make *ssa.MakeChan
+}
+
+// channelOf traces the channel value back to the instruction that makes it, through conversions.
+func channelOf(v ssa.Value) channel {
+	for {
+		switch c := v.(type) {
+		case *ssa.MakeChan:
+			pos := c.Parent().Prog.Fset.Position(c.Pos())
+			return channel{key: "make @" + pos.String(), label: c.Type().String(), make: c}
+		case *ssa.ChangeType:
+			v = c.X
+			continue
+		}
+		// the direction of the channel type differs between producers and consumers, the element type does not
+		label := v.Type().String()
+		if t, ok := v.Type().Underlying().(*types.Chan); ok {
+			label = "chan " + t.Elem().String()
+		}
+		return channel{key: "type " + label, label: label}
+	}
+}
+
+// channelOp is a send, receive or close of a channel.
+type channelOp struct {
+	class string
+	ch    channel
+}
+
+// channelOps returns the channel operations of the function: sends, receives, in select statements too, and closes.
+func channelOps(fn *ssa.Function) []channelOp {
+	var ops []channelOp
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch i := instr.(type) {
+			case *ssa.Send:
+				ops = append(ops, channelOp{"sends", channelOf(i.Chan)})
+			case *ssa.UnOp:
+				if i.Op == token.ARROW {
+					ops = append(ops, channelOp{"receives", channelOf(i.X)})
+				}
+			case *ssa.Select:
+				for _, state := range i.States {
+					class := "receives"
+					if state.Dir == types.SendOnly {
+						class = "sends"
+					}
+					ops = append(ops, channelOp{class, channelOf(state.Chan)})
+				}
+			case *ssa.Call:
+				if b, ok := i.Call.Value.(*ssa.Builtin); ok && b.Name() == "close" && len(i.Call.Args) == 1 {
+					ops = append(ops, channelOp{"closes", channelOf(i.Call.Args[0])})
+				}
+			}
+		}
+	}
+	return ops
+}
+
+// processChannel returns the ID of the node of the channel, creating it if it does not exist yet.
+// Channels traced back to their make are nested in the package of the function that makes them.
+func (cg *CytoGraph) processChannel(ch channel) CytoID {
+	isNew, id := cg.GetID("chan ~ "+ch.key, true)
+	if !isNew {
+		return id
+	}
+	desc := ch.key
+	n := newCytoNode(NodeData{Id: id, Label: ch.label, Description: &desc})
+	if ch.make != nil && ch.make.Parent().Pkg != nil {
+		n.Data.Parent = cg.ProcessPkg(ch.make.Parent().Pkg.Pkg)
+	}
+	n.Data.Color = integersToColor(stringToIntHash(ch.label)).Hex()
+	n.Classes = append(n.Classes, "channel")
+	cg.Nodes[id] = n
+	return id
+}
+
+// processChannelOps adds edges for the channel operations of the function: of the "sends" and "closes" classes
+// from the function node to the channel node, and of the "receives" class from the channel node to the function node,
+// so the edges run from producers, through channels, to consumers.
+func (cg *CytoGraph) processChannelOps(fnID CytoID, fn *ssa.Function) {
+	ops := channelOps(fn)
+	// the channels get their IDs in order of their key, for deterministic output
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].ch.key < ops[j].ch.key })
+	for _, op := range ops {
+		chID := cg.processChannel(op.ch)
+		isNew, id := cg.GetID(fmt.Sprintf("%s ~ %s -> %s", op.class, fnID, op.ch.key), false)
+		if !isNew {
+			continue
+		}
+		e := newCytoEdge(EdgeData{Id: id, Source: fnID, Target: chID})
+		if op.class == "receives" {
+			e.Data.Source, e.Data.Target = chID, fnID
+		}
+		e.Classes = append(e.Classes, op.class)
+		cg.Edges[id] = e
+	}
+}
@@ -0,0 +1,40 @@
+package render
+
+import (
+	"sort"
+
+	"github.com/protolambda/gocyto/graph"
+)
+
+// cluster assigns the function nodes to clusters of closely connected functions, with label propagation:
+// each function repeatedly joins the cluster most of its callers and callees are in, until the clusters are stable.
+// Clusters are numbered from 1, in the order of their first function ID, for deterministic output.
+func (cg *CytoGraph) cluster() {
+	out, in := cg.adjacency()
+	ids := make([]CytoID, 0, len(out)+len(in))
+	for id := range out {
+		ids = append(ids, id)
+	}
+	for id := range in {
+		if _, ok := out[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+
+	index := make(map[CytoID]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+	g := graph.NewDigraph(len(ids))
+	for i, id := range ids {
+		for _, callee := range out[id] {
+			g.AddEdge(i, index[callee])
+		}
+	}
+	for i, label := range graph.LabelPropagation(g) {
+		if n, ok := cg.Nodes[ids[i]]; ok {
+			n.Data.Cluster = label + 1
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package render
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// blocks returns the blocks of a control flow graph, with the successors of each block given by index.
+func blocks(succs ...[]int) []*ssa.BasicBlock {
+	out := make([]*ssa.BasicBlock, len(succs))
+	for i := range out {
+		out[i] = new(ssa.BasicBlock)
+	}
+	for i, s := range succs {
+		for _, j := range s {
+			out[i].Succs = append(out[i].Succs, out[j])
+		}
+	}
+	return out
+}
+
+func TestCyclomaticComplexity(t *testing.T) {
+	withRecover := blocks([]int{1, 2}, nil, nil, []int{2})
+	cases := []struct {
+		name string
+		fn   *ssa.Function
+		want int
+	}{
+		{"external", &ssa.Function{}, 0},
+		{"straight", &ssa.Function{Blocks: blocks(nil)}, 1},
+		{"if", &ssa.Function{Blocks: blocks([]int{1, 2}, []int{2}, nil)}, 2},
+		{"if else", &ssa.Function{Blocks: blocks([]int{1, 2}, []int{3}, []int{3}, nil)}, 2},
+		{"loop", &ssa.Function{Blocks: blocks([]int{1}, []int{2, 3}, []int{1}, nil)}, 2},
+		{"switch", &ssa.Function{Blocks: blocks([]int{1, 2, 3, 4}, nil, nil, nil, nil)}, 4},
+		{"nested", &ssa.Function{Blocks: blocks([]int{1, 4}, []int{2, 3}, []int{3}, []int{4}, nil)}, 3},
+		{"recover", &ssa.Function{Blocks: withRecover, Recover: withRecover[3]}, 2},
+		{"unreachable", &ssa.Function{Blocks: blocks(nil, []int{0, 1})}, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := cyclomaticComplexity(c.fn); got != c.want {
+				t.Errorf("got complexity %d, expected %d", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package render
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Kinds of conditions a call site can depend on, used as edge classes besides "conditional".
+const (
+	ErrorCheck    = "error-check"
+	PlatformCheck = "platform-check"
+	FlagCheck     = "flag-check"
+)
+
+// conditionOf recognizes the condition of a branch: a comparison of an error with nil,
+// or a load of an exported boolean package-level variable or struct field, possibly negated.
+// Flags are recognized with a heuristic: exported booleans are assumed to be configuration,
+// while unexported ones, e.g. s.closed, are assumed to be state, and are not recognized.
+// The name of the flag is returned for flag checks.
+func conditionOf(cond ssa.Value) (kind string, flag string) {
+	if not, ok := cond.(*ssa.UnOp); ok && not.Op == token.NOT {
+		cond = not.X
+	}
+	switch c := cond.(type) {
+	case *ssa.BinOp:
+		if c.Op != token.EQL && c.Op != token.NEQ {
+			return "", ""
+		}
+		errType := types.Universe.Lookup("error").Type()
+		for _, pair := range [][2]ssa.Value{{c.X, c.Y}, {c.Y, c.X}} {
+			if k, ok := pair[1].(*ssa.Const); ok && k.Value == nil && types.Identical(pair[0].Type(), errType) {
+				return ErrorCheck, ""
+			}
+		}
+	case *ssa.UnOp:
+		// a load of a boolean variable
+		if c.Op != token.MUL {
+			return "", ""
+		}
+		switch addr := c.X.(type) {
+		case *ssa.Global:
+			if addr.Object().Exported() {
+				return FlagCheck, addr.String()
+			}
+		case *ssa.FieldAddr:
+			if ptr, ok := addr.X.Type().Underlying().(*types.Pointer); ok {
+				if st, ok := ptr.Elem().Underlying().(*types.Struct); ok && st.Field(addr.Field).Exported() {
+					return FlagCheck, types.TypeString(ptr.Elem(), nil) + "." + st.Field(addr.Field).Name()
+				}
+			}
+		}
+	case *ssa.Field:
+		if st, ok := c.X.Type().Underlying().(*types.Struct); ok && st.Field(c.Field).Exported() {
+			return FlagCheck, types.TypeString(c.X.Type(), nil) + "." + st.Field(c.Field).Name()
+		}
+	}
+	return "", ""
+}
+
+// platformChecked tells if the position is in one of the branches, of if and switch statements on runtime.GOOS or runtime.GOARCH.
+func platformChecked(branches [][2]token.Pos, pos token.Pos) bool {
+	if !pos.IsValid() {
+		return false
+	}
+	for _, b := range branches {
+		if pos >= b[0] && pos < b[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// callCondition returns the kind of the nearest recognized condition the call site depends on,
+// and the name of the flag for flag checks. The call depends on a condition if it is in a block
+// that is only entered through one branch of it. Platform checks are recognized by the position ranges of their branches.
+// Empty if no condition is recognized.
+func callCondition(site ssa.CallInstruction, platformBranches [][2]token.Pos) (kind string, flag string) {
+	b := site.Block()
+	for d := b.Idom(); d != nil; d = d.Idom() {
+		ifInstr, ok := d.Instrs[len(d.Instrs)-1].(*ssa.If)
+		if !ok {
+			continue
+		}
+		for _, succ := range d.Succs {
+			// the successor is the merge point if it can be entered otherwise
+			if len(succ.Preds) == 1 && succ.Dominates(b) {
+				if kind, flag := conditionOf(ifInstr.Cond); kind != "" {
+					return kind, flag
+				}
+			}
+		}
+	}
+	if platformChecked(platformBranches, site.Pos()) {
+		return PlatformCheck, ""
+	}
+	return "", ""
+}
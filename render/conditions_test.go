@@ -0,0 +1,120 @@
+package render
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+const testConditions = `package app
+
+type Config struct{ Verbose bool }
+
+type server struct{ closed bool }
+
+var Debug bool
+
+var quiet bool
+
+func log() {}
+
+func errorCheck(err error) {
+	if err != nil {
+		log()
+	}
+}
+
+func exportedField(c *Config) {
+	if c.Verbose {
+		log()
+	}
+}
+
+func exportedValueField(c Config) {
+	if !c.Verbose {
+		log()
+	}
+}
+
+func exportedGlobal() {
+	if Debug {
+		log()
+	}
+}
+
+func unexportedField(s *server) {
+	if s.closed {
+		log()
+	}
+}
+
+func unexportedGlobal() {
+	if quiet {
+		log()
+	}
+}
+
+func unconditional() {
+	log()
+}
+
+func platform() {
+	log()
+}
+`
+
+func TestCallCondition(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "app.go", testConditions, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := types.NewPackage("example.com/app", "app")
+	ssaPkg, _, err := ssautil.BuildPackage(&types.Config{}, fset, pkg, []*ast.File{f}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// site returns the call of log in the function.
+	site := func(name string) ssa.CallInstruction {
+		for _, b := range ssaPkg.Func(name).Blocks {
+			for _, instr := range b.Instrs {
+				if call, ok := instr.(ssa.CallInstruction); ok && call.Common().StaticCallee() == ssaPkg.Func("log") {
+					return call
+				}
+			}
+		}
+		t.Fatalf("no call of log in %s", name)
+		return nil
+	}
+	platformSite := site("platform")
+	branches := [][2]token.Pos{{platformSite.Pos(), platformSite.Pos() + 1}}
+
+	cases := []struct {
+		fn   string
+		kind string
+		flag string
+	}{
+		{"errorCheck", ErrorCheck, ""},
+		{"exportedField", FlagCheck, "example.com/app.Config.Verbose"},
+		{"exportedValueField", FlagCheck, "example.com/app.Config.Verbose"},
+		{"exportedGlobal", FlagCheck, "example.com/app.Debug"},
+		// unexported booleans are state, not flags
+		{"unexportedField", "", ""},
+		{"unexportedGlobal", "", ""},
+		{"unconditional", "", ""},
+		{"platform", PlatformCheck, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.fn, func(t *testing.T) {
+			kind, flag := callCondition(site(c.fn), branches)
+			if kind != c.kind || flag != c.flag {
+				t.Errorf("got condition %q with flag %q, want %q with flag %q", kind, flag, c.kind, c.flag)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// isContext tells if the type is context.Context.
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
+}
+
+// hasContext tells if the function accepts a context, as parameter, or for closures, as free variable.
+func hasContext(fn *ssa.Function) bool {
+	for _, p := range fn.Params {
+		if isContext(p.Type()) {
+			return true
+		}
+	}
+	for _, fv := range fn.FreeVars {
+		if isContext(fv.Type()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRootContext tells if the value is a new empty context, of context.Background or context.TODO.
+func isRootContext(v ssa.Value) bool {
+	call, ok := v.(*ssa.Call)
+	if !ok {
+		return false
+	}
+	callee := call.Call.StaticCallee()
+	return callee != nil && callee.Pkg != nil && callee.Pkg.Pkg.Path() == "context" &&
+		(callee.Name() == "Background" || callee.Name() == "TODO")
+}
+
+// contextWarning returns the class of the call if it breaks the propagation of contexts: "context-missing"
+// if the callee accepts a context but the caller has none to pass on, or "context-dropped" if the caller
+// has a context, but passes a new empty one instead. Empty if the call propagates contexts, or if the callee
+// accepts no context. Calls of the context package itself, which derive contexts, are not checked.
+func contextWarning(edge *callgraph.Edge) string {
+	if edge.Site == nil || !hasContext(edge.Callee.Func) {
+		return ""
+	}
+	if pkg := edge.Callee.Func.Pkg; pkg != nil && pkg.Pkg.Path() == "context" {
+		return ""
+	}
+	if !hasContext(edge.Caller.Func) {
+		return "context-missing"
+	}
+	for _, arg := range edge.Site.Common().Args {
+		if isContext(arg.Type()) && isRootContext(arg) {
+			return "context-dropped"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,169 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sccs returns the strongly connected components of the graph, following the edges, with Tarjan's algorithm.
+// Nodes are visited in ID order, for stable output.
+func (cg *CytoGraph) sccs() [][]CytoID {
+	out, _ := cg.adjacency()
+	ids := make([]CytoID, 0, len(out))
+	for id := range out {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	index := make(map[CytoID]int)
+	low := make(map[CytoID]int)
+	onStack := make(map[CytoID]bool)
+	var stack []CytoID
+	var components [][]CytoID
+	var visit func(id CytoID)
+	visit = func(id CytoID) {
+		index[id] = len(index)
+		low[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+		for _, next := range out[id] {
+			if _, ok := index[next]; !ok {
+				visit(next)
+				if low[next] < low[id] {
+					low[id] = low[next]
+				}
+			} else if onStack[next] && index[next] < low[id] {
+				low[id] = index[next]
+			}
+		}
+		if low[id] != index[id] {
+			return
+		}
+		var component []CytoID
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		components = append(components, component)
+	}
+	for _, id := range ids {
+		if _, ok := index[id]; !ok {
+			visit(id)
+		}
+	}
+	return components
+}
+
+// displayName is the fully qualified name of a function node, or the label of other nodes.
+func displayName(n *CytoNode) string {
+	if n.Data.Name != "" {
+		return n.Data.Name
+	}
+	return n.Data.Label
+}
+
+// markCycles marks the edges within call cycles, direct and mutual recursion, with the cycle class,
+// and reports the functions of each cycle in the "cycles" report. Previous marks are replaced.
+func (cg *CytoGraph) markCycles() {
+	components := cg.sccs()
+	component := make(map[CytoID]int)
+	for i, c := range components {
+		for _, id := range c {
+			component[id] = i
+		}
+	}
+	recursive := make(map[int]bool)
+	for _, e := range cg.Edges {
+		e.Classes = removeClass(e.Classes, "cycle")
+		// an edge within a component is part of a cycle; a component of a single node only has self-calls
+		if component[e.Data.Source] == component[e.Data.Target] {
+			e.Classes = append(e.Classes, "cycle")
+			recursive[component[e.Data.Source]] = true
+		}
+	}
+	var cycles [][]string
+	for i, c := range components {
+		if !recursive[i] {
+			continue
+		}
+		var names []string
+		for _, id := range c {
+			if n, ok := cg.Nodes[id]; ok {
+				names = append(names, displayName(n))
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+		cycles = append(cycles, names)
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	delete(cg.Reports, "cycles")
+	if len(cycles) > 0 {
+		cg.AddReport("cycles", cycles)
+	}
+}
+
+func removeClass(classes []string, class string) []string {
+	out := classes[:0]
+	for _, c := range classes {
+		if c != class {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// condense collapses each strongly connected component of multiple functions into a node of class scc,
+// with the count of functions per package, making the call graph a DAG. Self-calls are removed.
+// The component node is nested in the package of its functions, if they share one.
+func (cg *CytoGraph) condense() {
+	groups := make(map[CytoID]CytoID)
+	components := cg.sccs()
+	for _, c := range components {
+		sort.Slice(c, func(i, j int) bool { return lessID(c[i], c[j]) })
+	}
+	// component nodes get their IDs in the order of their functions, for deterministic output
+	sort.Slice(components, func(i, j int) bool { return lessID(components[i][0], components[j][0]) })
+	for _, c := range components {
+		if len(c) < 2 {
+			continue
+		}
+		_, id := cg.GetID("scc ~ "+string(c[0]), true)
+		node := newCytoNode(NodeData{Id: id, Count: len(c), Packages: make(map[string]int)})
+		node.Classes = append(node.Classes, "scc")
+		var names []string
+		pkgNodes := make(map[*CytoNode]bool)
+		for _, m := range c {
+			n, ok := cg.Nodes[m]
+			if !ok {
+				continue
+			}
+			names = append(names, n.Data.Label)
+			node.Data.Packages[cg.nodePackage(n)]++
+			pkgNodes[cg.packageNode(n)] = true
+			groups[m] = id
+		}
+		sort.Strings(names)
+		node.Data.Label = fmt.Sprintf("%d functions: %s", len(c), strings.Join(names, ", "))
+		for p := range pkgNodes {
+			if p != nil && len(pkgNodes) == 1 {
+				node.Data.Parent = p.Data.Id
+			}
+		}
+		cg.Nodes[id] = node
+	}
+	cg.contract(groups)
+	for id, e := range cg.Edges {
+		if e.Data.Source == e.Data.Target {
+			delete(cg.Edges, id)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSCCs(t *testing.T) {
+	cases := []struct {
+		name       string
+		calls      []string
+		components [][]string
+	}{
+		{"acyclic", []string{"a->b", "b->c"}, [][]string{{"a"}, {"b"}, {"c"}}},
+		{"self call", []string{"a->a", "a->b"}, [][]string{{"a"}, {"b"}}},
+		{"mutual recursion", []string{"a->b", "b->a", "b->c"}, [][]string{{"a", "b"}, {"c"}}},
+		{"two cycles", []string{"a->b", "b->a", "b->c", "c->d", "d->e", "e->c"}, [][]string{{"a", "b"}, {"c", "d", "e"}}},
+		{"joined cycles", []string{"a->b", "b->a", "b->c", "c->b"}, [][]string{{"a", "b", "c"}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cg := testGraph(c.calls...)
+			var got [][]string
+			for _, component := range cg.sccs() {
+				var names []string
+				for _, id := range component {
+					names = append(names, testLabel(cg, id))
+				}
+				sort.Strings(names)
+				got = append(got, names)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i][0] < got[j][0] })
+			if !reflect.DeepEqual(got, c.components) {
+				t.Errorf("got components %v, expected %v", got, c.components)
+			}
+		})
+	}
+}
+
+func TestCondense(t *testing.T) {
+	cases := []struct {
+		name  string
+		calls []string
+		edges []string
+	}{
+		{"acyclic", []string{"a->b", "b->c"}, []string{"a->b", "b->c"}},
+		{"self call", []string{"a->a", "a->b"}, []string{"a->b"}},
+		{"cycle", []string{"a->b", "b->a", "b->c", "a->c", "d->a"},
+			[]string{"2 functions: a, b->c", "d->2 functions: a, b"}},
+		{"two cycles", []string{"a->b", "b->a", "b->c", "c->d", "d->c"},
+			[]string{"2 functions: a, b->2 functions: c, d"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cg := testGraph(c.calls...)
+			cg.condense()
+			if got := testEdges(cg); !reflect.DeepEqual(got, c.edges) {
+				t.Errorf("got edges %v, expected %v", got, c.edges)
+			}
+			for _, n := range cg.Nodes {
+				if !n.HasClass("scc") {
+					continue
+				}
+				if n.Data.Packages["example.com/pkg"] != n.Data.Count {
+					t.Errorf("component %s counts %v functions per package, expected %d in example.com/pkg",
+						n.Data.Label, n.Data.Packages, n.Data.Count)
+				}
+				if n.Data.Parent != cg.idMap["pkg ~ example.com/pkg"] {
+					t.Errorf("component %s is not nested in its package", n.Data.Label)
+				}
+			}
+		})
+	}
+}
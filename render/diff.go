@@ -0,0 +1,240 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type FuncRename struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+type Call struct {
+	Caller string `json:"caller"`
+	Callee string `json:"callee"`
+}
+
+// VersionChange is a call that exists in both graphs, to a function of a module that changed version.
+type VersionChange struct {
+	Call
+	Module     string `json:"module"`
+	OldVersion string `json:"old_version"`
+	NewVersion string `json:"new_version"`
+}
+
+// GraphDiff is the structural difference between two graphs of the same program, e.g. two revisions of it.
+// Functions are identified by their fully qualified name, calls by the names of both ends.
+type GraphDiff struct {
+	AddedFuncs   []string     `json:"added_funcs"`
+	RemovedFuncs []string     `json:"removed_funcs"`
+	RenamedFuncs []FuncRename `json:"renamed_funcs"`
+	AddedCalls   []Call       `json:"added_calls"`
+	RemovedCalls []Call       `json:"removed_calls"`
+	// VersionChanges lists the unchanged calls that now target a different module version.
+	VersionChanges []VersionChange `json:"version_changes"`
+}
+
+func (cg *CytoGraph) funcsByName() map[string]*CytoNode {
+	out := make(map[string]*CytoNode)
+	for _, n := range cg.Nodes {
+		if n.Data.Name != "" {
+			out[n.Data.Name] = n
+		}
+	}
+	return out
+}
+
+// calls returns the calls between functions. Other edges, e.g. to variables or between packages,
+// and plugin boundaries, depend on the options the graph was loaded with, not on the program.
+func (cg *CytoGraph) calls() map[Call]struct{} {
+	out := make(map[Call]struct{})
+	for _, e := range cg.Edges {
+		if !isCall(e) || e.HasClass("plugin-boundary") {
+			continue
+		}
+		src, dst := cg.Nodes[e.Data.Source], cg.Nodes[e.Data.Target]
+		if src == nil || dst == nil || src.Data.Name == "" || dst.Data.Name == "" {
+			continue
+		}
+		out[Call{Caller: src.Data.Name, Callee: dst.Data.Name}] = struct{}{}
+	}
+	return out
+}
+
+// funcQualifier is the package or receiver part of a fully qualified function name.
+func funcQualifier(name string) string {
+	if last := strings.LastIndex(name, "."); last >= 0 {
+		return name[:last]
+	}
+	return ""
+}
+
+// renameCandidate tells if b may be a.
+// Renamed functions keep their package or receiver, their signature, and the file they are declared in.
+func renameCandidate(a *NodeData, b *NodeData) bool {
+	return a.Signature == b.Signature &&
+		funcQualifier(a.Name) == funcQualifier(b.Name) &&
+		filepath.Base(a.File) == filepath.Base(b.File)
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// DiffGraphs compares the functions and calls of two graphs.
+// A removed and an added function are reported as a rename instead,
+// if they have the same package or receiver, signature and file. The closest declarations are paired up first.
+func DiffGraphs(prev *CytoGraph, next *CytoGraph) *GraphDiff {
+	oldFuncs, newFuncs := prev.funcsByName(), next.funcsByName()
+	var removed, added []*NodeData
+	for name, n := range oldFuncs {
+		if _, ok := newFuncs[name]; !ok {
+			removed = append(removed, &n.Data)
+		}
+	}
+	for name, n := range newFuncs {
+		if _, ok := oldFuncs[name]; !ok {
+			added = append(added, &n.Data)
+		}
+	}
+
+	type pair struct {
+		old, new *NodeData
+		dist     int
+	}
+	var pairs []pair
+	for _, a := range removed {
+		for _, b := range added {
+			if renameCandidate(a, b) {
+				pairs = append(pairs, pair{a, b, absInt(a.Line - b.Line)})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].dist != pairs[j].dist {
+			return pairs[i].dist < pairs[j].dist
+		}
+		if pairs[i].old.Name != pairs[j].old.Name {
+			return pairs[i].old.Name < pairs[j].old.Name
+		}
+		return pairs[i].new.Name < pairs[j].new.Name
+	})
+
+	diff := &GraphDiff{}
+	// renames maps old names to new names
+	renames := make(map[string]string)
+	renamed := make(map[string]bool)
+	for _, p := range pairs {
+		if _, ok := renames[p.old.Name]; ok || renamed[p.new.Name] {
+			continue
+		}
+		renames[p.old.Name] = p.new.Name
+		renamed[p.new.Name] = true
+		diff.RenamedFuncs = append(diff.RenamedFuncs, FuncRename{Old: p.old.Name, New: p.new.Name})
+	}
+	for _, a := range removed {
+		if _, ok := renames[a.Name]; !ok {
+			diff.RemovedFuncs = append(diff.RemovedFuncs, a.Name)
+		}
+	}
+	for _, b := range added {
+		if !renamed[b.Name] {
+			diff.AddedFuncs = append(diff.AddedFuncs, b.Name)
+		}
+	}
+
+	// compare calls under the new names, so calls of renamed functions are not reported as changed.
+	rename := func(name string) string {
+		if n, ok := renames[name]; ok {
+			return n
+		}
+		return name
+	}
+	oldCalls := make(map[Call]struct{})
+	for c := range prev.calls() {
+		oldCalls[Call{Caller: rename(c.Caller), Callee: rename(c.Callee)}] = struct{}{}
+	}
+	newCalls := next.calls()
+	// version changes are checked for calls in both graphs. Renamed callees are skipped: the old graph lacks their new name.
+	for c := range oldCalls {
+		if _, ok := newCalls[c]; !ok {
+			diff.RemovedCalls = append(diff.RemovedCalls, c)
+			continue
+		}
+		prevCallee, nextCallee := oldFuncs[c.Callee], newFuncs[c.Callee]
+		if prevCallee == nil || nextCallee == nil || prevCallee.Data.Module == "" {
+			continue
+		}
+		if prevCallee.Data.Module == nextCallee.Data.Module && prevCallee.Data.Version != nextCallee.Data.Version {
+			diff.VersionChanges = append(diff.VersionChanges, VersionChange{
+				Call:       c,
+				Module:     nextCallee.Data.Module,
+				OldVersion: prevCallee.Data.Version,
+				NewVersion: nextCallee.Data.Version,
+			})
+		}
+	}
+	for c := range newCalls {
+		if _, ok := oldCalls[c]; !ok {
+			diff.AddedCalls = append(diff.AddedCalls, c)
+		}
+	}
+
+	sort.Strings(diff.AddedFuncs)
+	sort.Strings(diff.RemovedFuncs)
+	sort.Slice(diff.RenamedFuncs, func(i, j int) bool { return diff.RenamedFuncs[i].Old < diff.RenamedFuncs[j].Old })
+	sortCalls(diff.AddedCalls)
+	sortCalls(diff.RemovedCalls)
+	sort.Slice(diff.VersionChanges, func(i, j int) bool {
+		a, b := diff.VersionChanges[i], diff.VersionChanges[j]
+		if a.Caller != b.Caller {
+			return a.Caller < b.Caller
+		}
+		return a.Callee < b.Callee
+	})
+	return diff
+}
+
+func sortCalls(calls []Call) {
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].Caller != calls[j].Caller {
+			return calls[i].Caller < calls[j].Caller
+		}
+		return calls[i].Callee < calls[j].Callee
+	})
+}
+
+func (d *GraphDiff) WriteText(w io.Writer) error {
+	var lines []string
+	for _, f := range d.RemovedFuncs {
+		lines = append(lines, "- func "+f)
+	}
+	for _, f := range d.AddedFuncs {
+		lines = append(lines, "+ func "+f)
+	}
+	for _, r := range d.RenamedFuncs {
+		lines = append(lines, fmt.Sprintf("~ func %s -> %s", r.Old, r.New))
+	}
+	for _, c := range d.RemovedCalls {
+		lines = append(lines, fmt.Sprintf("- call %s -> %s", c.Caller, c.Callee))
+	}
+	for _, c := range d.AddedCalls {
+		lines = append(lines, fmt.Sprintf("+ call %s -> %s", c.Caller, c.Callee))
+	}
+	for _, c := range d.VersionChanges {
+		lines = append(lines, fmt.Sprintf("! call %s -> %s: %s %s -> %s", c.Caller, c.Callee, c.Module, c.OldVersion, c.NewVersion))
+	}
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDiffGraphs(t *testing.T) {
+	cases := []struct {
+		name  string
+		prev  []string
+		next  []string
+		setup func(prev *CytoGraph, next *CytoGraph)
+		diff  GraphDiff
+	}{
+		{name: "same", prev: []string{"a->b"}, next: []string{"a->b"}},
+		{
+			name: "added and removed",
+			prev: []string{"a->b", "a->c"},
+			next: []string{"a->b", "b->d"},
+			setup: func(prev *CytoGraph, next *CytoGraph) {
+				// a different signature rules out a rename
+				testFunc(next, "d").Data.Signature = "func() error"
+			},
+			diff: GraphDiff{
+				AddedFuncs:   []string{"pkg.d"},
+				RemovedFuncs: []string{"pkg.c"},
+				AddedCalls:   []Call{{Caller: "pkg.b", Callee: "pkg.d"}},
+				RemovedCalls: []Call{{Caller: "pkg.a", Callee: "pkg.c"}},
+			},
+		},
+		{
+			name: "renamed",
+			prev: []string{"a->b", "b->c"},
+			next: []string{"a->d", "d->c"},
+			diff: GraphDiff{RenamedFuncs: []FuncRename{{Old: "pkg.b", New: "pkg.d"}}},
+		},
+		{
+			name: "closest rename",
+			prev: []string{"a->b", "a->c"},
+			next: []string{"a->d", "a->e"},
+			setup: func(prev *CytoGraph, next *CytoGraph) {
+				testFunc(prev, "b").Data.Line = 10
+				testFunc(prev, "c").Data.Line = 20
+				testFunc(next, "d").Data.Line = 21
+				testFunc(next, "e").Data.Line = 11
+			},
+			diff: GraphDiff{RenamedFuncs: []FuncRename{{Old: "pkg.b", New: "pkg.e"}, {Old: "pkg.c", New: "pkg.d"}}},
+		},
+		{
+			name: "not calls",
+			prev: []string{"a->b"},
+			next: []string{"a->b"},
+			setup: func(prev *CytoGraph, next *CytoGraph) {
+				_, varID := next.GetID("global ~ pkg.v", true)
+				next.Nodes[varID] = newCytoNode(NodeData{Id: varID, Label: "v"})
+				edge := func(src CytoID, dst CytoID, class string) {
+					_, id := next.GetID(fmt.Sprintf("%s ~ %s -> %s", class, src, dst), false)
+					e := newCytoEdge(EdgeData{Id: id, Source: src, Target: dst})
+					e.Classes = append(e.Classes, class)
+					next.Edges[id] = e
+				}
+				a, b := testFunc(next, "a").Data.Id, testFunc(next, "b").Data.Id
+				edge(a, varID, "writes")
+				edge(b, a, "reads")
+				edge(b, a, "plugin-boundary")
+				edge(next.idMap["pkg ~ example.com/pkg"], a, "package-call")
+			},
+		},
+		{
+			name: "version change",
+			prev: []string{"a->b"},
+			next: []string{"a->b"},
+			setup: func(prev *CytoGraph, next *CytoGraph) {
+				prevB, nextB := testFunc(prev, "b"), testFunc(next, "b")
+				prevB.Data.Module, prevB.Data.Version = "example.com/dep", "v1.0.0"
+				nextB.Data.Module, nextB.Data.Version = "example.com/dep", "v1.1.0"
+			},
+			diff: GraphDiff{VersionChanges: []VersionChange{{
+				Call:       Call{Caller: "pkg.a", Callee: "pkg.b"},
+				Module:     "example.com/dep",
+				OldVersion: "v1.0.0",
+				NewVersion: "v1.1.0",
+			}}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			prev, next := testGraph(c.prev...), testGraph(c.next...)
+			if c.setup != nil {
+				c.setup(prev, next)
+			}
+			if got := DiffGraphs(prev, next); !reflect.DeepEqual(*got, c.diff) {
+				t.Errorf("got diff %+v, expected %+v", *got, c.diff)
+			}
+		})
+	}
+}
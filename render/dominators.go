@@ -0,0 +1,119 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+)
+
+// dominators computes the immediate dominator of each function node in the graph, with the algorithm of
+// Cooper, Harvey and Kennedy. The graph is entered from a virtual root, with the empty ID, that calls the functions
+// without callers, and one function of each cycle that cannot be reached otherwise.
+// A function dominates another if all call paths from the root to the other function go through it.
+func (cg *CytoGraph) dominators() map[CytoID]CytoID {
+	out, in := cg.adjacency()
+	ids := make([]CytoID, 0, len(out)+len(in))
+	for id := range out {
+		ids = append(ids, id)
+	}
+	for id := range in {
+		if _, ok := out[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	const root CytoID = ""
+	order := make(map[CytoID]int)
+	var postorder []CytoID
+	visited := make(map[CytoID]bool)
+	var visit func(id CytoID)
+	visit = func(id CytoID) {
+		visited[id] = true
+		for _, next := range out[id] {
+			if !visited[next] {
+				visit(next)
+			}
+		}
+		order[id] = len(postorder)
+		postorder = append(postorder, id)
+	}
+	entries := make(map[CytoID]bool)
+	for _, id := range ids {
+		if len(in[id]) == 0 {
+			entries[id] = true
+			visit(id)
+		}
+	}
+	for _, id := range ids {
+		if !visited[id] {
+			entries[id] = true
+			visit(id)
+		}
+	}
+	order[root] = len(postorder)
+
+	idom := map[CytoID]CytoID{root: root}
+	intersect := func(a, b CytoID) CytoID {
+		for a != b {
+			for order[a] < order[b] {
+				a = idom[a]
+			}
+			for order[b] < order[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		// reverse postorder, the root is already done
+		for i := len(postorder) - 1; i >= 0; i-- {
+			id := postorder[i]
+			preds := in[id]
+			if entries[id] {
+				preds = append([]CytoID{root}, preds...)
+			}
+			newIdom, found := root, false
+			for _, p := range preds {
+				if _, ok := idom[p]; !ok {
+					continue
+				}
+				if !found {
+					newIdom, found = p, true
+				} else {
+					newIdom = intersect(p, newIdom)
+				}
+			}
+			if cur, ok := idom[id]; !ok || cur != newIdom {
+				idom[id] = newIdom
+				changed = true
+			}
+		}
+	}
+	delete(idom, root)
+	return idom
+}
+
+// dominatorTree replaces the calls with the edges of the dominator tree, of class dominates,
+// from each function to the functions it immediately dominates. The functions without a dominator are the roots of the tree.
+func (cg *CytoGraph) dominatorTree() {
+	idom := cg.dominators()
+	for id := range cg.Edges {
+		delete(cg.Edges, id)
+	}
+	ids := make([]CytoID, 0, len(idom))
+	for id := range idom {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	for _, id := range ids {
+		parent := idom[id]
+		if parent == "" {
+			continue
+		}
+		_, edgeID := cg.GetID(fmt.Sprintf("dom ~ %s -> %s", parent, id), false)
+		e := newCytoEdge(EdgeData{Id: edgeID, Source: parent, Target: id})
+		e.Classes = append(e.Classes, "dominates")
+		cg.Edges[edgeID] = e
+	}
+}
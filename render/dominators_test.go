@@ -0,0 +1,51 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDominators(t *testing.T) {
+	cases := []struct {
+		name  string
+		calls []string
+		// idom maps functions to their immediate dominator, empty for the functions entered from the root
+		idom map[string]string
+	}{
+		{"chain", []string{"a->b", "b->c"}, map[string]string{"a": "", "b": "a", "c": "b"}},
+		{"diamond", []string{"a->b", "a->c", "b->d", "c->d", "d->e"},
+			map[string]string{"a": "", "b": "a", "c": "a", "d": "a", "e": "d"}},
+		{"cycle", []string{"a->b", "b->c", "c->b"}, map[string]string{"a": "", "b": "a", "c": "b"}},
+		{"unreachable cycle", []string{"x->y", "y->x"}, map[string]string{"x": "", "y": "x"}},
+		{"two entries", []string{"a->c", "b->c", "c->d"}, map[string]string{"a": "", "b": "", "c": "", "d": "c"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cg := testGraph(c.calls...)
+			got := make(map[string]string)
+			for id, dom := range cg.dominators() {
+				got[testLabel(cg, id)] = ""
+				if dom != "" {
+					got[testLabel(cg, id)] = testLabel(cg, dom)
+				}
+			}
+			if !reflect.DeepEqual(got, c.idom) {
+				t.Errorf("got dominators %v, expected %v", got, c.idom)
+			}
+		})
+	}
+}
+
+func TestDominatorTree(t *testing.T) {
+	cg := testGraph("a->b", "a->c", "b->d", "c->d", "d->e")
+	cg.dominatorTree()
+	expected := []string{"a->b", "a->c", "a->d", "d->e"}
+	if got := testEdges(cg); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got tree %v, expected %v", got, expected)
+	}
+	for _, e := range cg.Edges {
+		if !e.HasClass("dominates") {
+			t.Errorf("edge %s is not marked as dominates", e.Data.Id)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// processNamed returns the ID of the node of the named type, nested in its package, creating it if it does not exist yet.
+// The node has the "interface" class for interfaces, and the "struct" class for structs.
+func (cg *CytoGraph) processNamed(named *types.Named) CytoID {
+	obj := named.Obj()
+	isNew, id := cg.GetID(fmt.Sprintf("named ~ %s ~ %s", obj.Pkg().Path(), obj.Name()), true)
+	if !isNew {
+		return id
+	}
+	name := named.String()
+	n := newCytoNode(NodeData{
+		Id:          id,
+		Parent:      cg.ProcessPkg(obj.Pkg()),
+		Label:       obj.Name(),
+		Description: &name,
+	})
+	n.Data.Color = integersToColor(stringToIntHash(n.Data.Label)).Hex()
+	switch named.Underlying().(type) {
+	case *types.Interface:
+		n.Classes = append(n.Classes, "interface")
+	case *types.Struct:
+		n.Classes = append(n.Classes, "struct")
+	}
+	cg.Nodes[id] = n
+	return id
+}
+
+// embedded returns the named types embedded in the struct or interface, pointers to embedded structs are dereferenced.
+func embedded(named *types.Named) []*types.Named {
+	var out []*types.Named
+	add := func(t types.Type) {
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if n, ok := t.(*types.Named); ok && n.Obj().Pkg() != nil {
+			out = append(out, n)
+		}
+	}
+	switch u := named.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); f.Embedded() {
+				add(f.Type())
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < u.NumEmbeddeds(); i++ {
+			add(u.EmbeddedType(i))
+		}
+	}
+	return out
+}
+
+// LoadEmbeddingGraph adds the named types, and the types they embed transitively, as nodes in their package,
+// with an edge, of the "embeds" class, from each struct or interface to each type it embeds.
+// Types of the Go root are left out, unless included by the options. The graph is filtered with the options afterwards.
+func (cg *CytoGraph) LoadEmbeddingGraph(named []*types.Named, opts *RenderOptions) {
+	cg.opts = opts
+	seen := make(map[*types.Named]bool)
+	var visit func(t *types.Named)
+	visit = func(t *types.Named) {
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		for _, e := range embedded(t) {
+			if !opts.IncludeGoRoot && cg.pkgOrigin(e.Obj().Pkg().Path()) == StdlibOrigin {
+				continue
+			}
+			src, dst := cg.processNamed(t), cg.processNamed(e)
+			isNew, id := cg.GetID(fmt.Sprintf("embeds ~ %s -> %s", t, e), false)
+			if isNew {
+				edge := newCytoEdge(EdgeData{Id: id, Source: src, Target: dst})
+				edge.Classes = append(edge.Classes, "embeds")
+				cg.Edges[id] = edge
+			}
+			visit(e)
+		}
+	}
+	sorted := append([]*types.Named(nil), named...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].String() < sorted[j].String() })
+	for _, t := range sorted {
+		visit(t)
+	}
+	cg.Filter(opts)
+}
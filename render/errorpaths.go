@@ -0,0 +1,73 @@
+package render
+
+import (
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// returnsError tells if one of the results of the function is an error.
+func returnsError(fn *ssa.Function) bool {
+	errType := types.Universe.Lookup("error").Type()
+	results := fn.Signature.Results()
+	for i := 0; i < results.Len(); i++ {
+		if types.Identical(results.At(i).Type(), errType) {
+			return true
+		}
+	}
+	return false
+}
+
+// isErrorSurface tells if the node is an exported function that returns an error, where errors reach the API.
+func isErrorSurface(n *CytoNode) bool {
+	return n.HasClass("returns-error") && !n.HasClass("unexported") && n.Data.Name != "" && !strings.Contains(n.Data.Name, "$")
+}
+
+// errorPaths only keeps the calls between functions that return errors, that are reachable from an exported function,
+// the call chains errors can propagate up to the API through. The exported functions get the error-surface class,
+// and the functions at the end of the chains, that produce errors rather than pass them on, the error-origin class.
+// Edges that are not calls, e.g. of implementations, are only kept between the functions on the chains.
+func (cg *CytoGraph) errorPaths() {
+	for id, e := range cg.Edges {
+		if !isCall(e) {
+			continue
+		}
+		caller, ok := cg.Nodes[e.Data.Source]
+		if !ok || !caller.HasClass("returns-error") {
+			delete(cg.Edges, id)
+			continue
+		}
+		callee, ok := cg.Nodes[e.Data.Target]
+		if !ok || !callee.HasClass("returns-error") {
+			delete(cg.Edges, id)
+		}
+	}
+	var surfaces []CytoID
+	for id, n := range cg.Nodes {
+		if isErrorSurface(n) {
+			surfaces = append(surfaces, id)
+		}
+	}
+	out, _ := cg.adjacency()
+	reached := hopDistances(surfaces, out, -1)
+	for id, e := range cg.Edges {
+		_, sourceReached := reached[e.Data.Source]
+		_, targetReached := reached[e.Data.Target]
+		if !sourceReached || (!isCall(e) && !targetReached) {
+			delete(cg.Edges, id)
+		}
+	}
+	for id := range reached {
+		n, ok := cg.Nodes[id]
+		if !ok {
+			continue
+		}
+		if isErrorSurface(n) {
+			n.Classes = appendUnique(n.Classes, "error-surface")
+		}
+		if len(out[id]) == 0 {
+			n.Classes = appendUnique(n.Classes, "error-origin")
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package render
+
+// keepGated removes the edges to functions that are not gated by the feature flag,
+// leaving the subgraph of the flag, entered through the guarded calls.
+func (cg *CytoGraph) keepGated(flag string) {
+	for id, e := range cg.Edges {
+		n, ok := cg.Nodes[e.Data.Target]
+		if !ok || !gatedBy(n, flag) {
+			delete(cg.Edges, id)
+		}
+	}
+}
+
+func gatedBy(n *CytoNode, flag string) bool {
+	for _, f := range n.Data.FeatureFlags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package render
+
+import (
+	"sort"
+	"strconv"
+)
+
+func (n *CytoNode) HasClass(class string) bool {
+	for _, c := range n.Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *CytoEdge) HasClass(class string) bool {
+	for _, c := range e.Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// keepCallee tells if calls to the function node are kept by the options.
+func (opts *RenderOptions) keepCallee(n *CytoNode) bool {
+	if !opts.IncludeGoRoot && n.HasClass("go_root") {
+		return false
+	}
+	// cgo stubs are unexported, but the C functions they stand for are the boundary of the program
+	if !opts.IncludeUnexported && n.HasClass("unexported") && !n.HasClass("c-function") {
+		return false
+	}
+	if opts.ExcludeTestDoubles && n.HasClass("test_double") {
+		return false
+	}
+	if opts.WithinModule && !n.HasClass(InternalOrigin) {
+		return false
+	}
+	return true
+}
+
+// nodePackage returns the import path of the package the node is nested in, or "" if unknown.
+func (cg *CytoGraph) nodePackage(n *CytoNode) string {
+	pkgNode := cg.packageNode(n)
+	if pkgNode == nil || pkgNode.Data.Description == nil {
+		return ""
+	}
+	return *pkgNode.Data.Description
+}
+
+// keepPkg tells if functions of the package are kept by the package include and exclude patterns of the options.
+func (opts *RenderOptions) keepPkg(path string) bool {
+	if opts.IncludePkg != nil && !opts.IncludePkg.MatchString(path) {
+		return false
+	}
+	if opts.ExcludePkg != nil && opts.ExcludePkg.MatchString(path) {
+		return false
+	}
+	return true
+}
+
+// keepFunc tells if the function node is kept by the function include and exclude patterns of the options.
+func (opts *RenderOptions) keepFunc(n *CytoNode) bool {
+	if opts.IncludeFunc != nil && !opts.IncludeFunc.MatchString(n.Data.Name) {
+		return false
+	}
+	if opts.ExcludeFunc != nil && opts.ExcludeFunc.MatchString(n.Data.Name) {
+		return false
+	}
+	return true
+}
+
+// keepEdge tells if the call is kept by the options.
+func (cg *CytoGraph) keepEdge(opts *RenderOptions, e *CytoEdge) bool {
+	caller, ok := cg.Nodes[e.Data.Source]
+	if !ok {
+		return false
+	}
+	callee, ok := cg.Nodes[e.Data.Target]
+	if !ok || !opts.keepCallee(callee) {
+		return false
+	}
+	if opts.ExcludeTestDoubles && caller.HasClass("test_double") {
+		return false
+	}
+	if !opts.keepFunc(caller) || !opts.keepFunc(callee) {
+		return false
+	}
+	if opts.Package != "" && (cg.nodePackage(caller) != opts.Package || cg.nodePackage(callee) != opts.Package) {
+		return false
+	}
+	if opts.ExcludeDeferred && e.HasClass("deferred") {
+		return false
+	}
+	if opts.Goroutines && !e.HasClass("concurrent") {
+		return false
+	}
+	if opts.CrossPackage && cg.nodePackage(caller) == cg.nodePackage(callee) {
+		return false
+	}
+	if opts.IncludePkg != nil || opts.ExcludePkg != nil {
+		return opts.keepPkg(cg.nodePackage(caller)) && opts.keepPkg(cg.nodePackage(callee))
+	}
+	return true
+}
+
+// Filter removes the edges excluded by the options, groups the nodes at the granularity of the options,
+// and then prunes the graph. The call cycles of the remaining graph are marked and reported.
+// Filtering works the same on a graph read back with ReadJson, as on a graph that is being loaded.
+func (cg *CytoGraph) Filter(opts *RenderOptions) {
+	for id, e := range cg.Edges {
+		if !cg.keepEdge(opts, e) {
+			delete(cg.Edges, id)
+		}
+	}
+	// instantiations are folded first, so the other options see a single node per generic function
+	if opts.FoldInstantiations {
+		cg.foldInstantiations()
+	}
+	// focus after filtering: the hops are counted in the filtered graph.
+	if opts.Focus != "" {
+		cg.focus(opts.Focus, opts.FocusHops)
+	}
+	if opts.GatedBy != "" {
+		cg.keepGated(opts.GatedBy)
+	}
+	if opts.ErrorPaths {
+		cg.errorPaths()
+	}
+	if opts.Unsafe {
+		cg.keepUnsafe()
+	}
+	switch opts.Granularity {
+	case FileGranularity:
+		cg.groupByFile()
+	case TypeGranularity:
+		cg.groupByType()
+	case PackageGranularity:
+		cg.groupByPackage()
+	}
+	if opts.MergeCalls {
+		cg.mergeCalls()
+	}
+	if opts.Condense {
+		cg.condense()
+	}
+	if opts.Dominators {
+		cg.dominatorTree()
+	}
+	cg.Prune()
+	// packages are nested in their module first, the packages without module can still be nested by path
+	if opts.NestModules {
+		cg.nestModules()
+	}
+	if opts.NestPaths {
+		cg.nestPaths()
+	}
+	cg.markCycles()
+	if opts.Betweenness {
+		cg.betweenness(opts.BetweennessSamples)
+	}
+	if opts.Rank {
+		cg.pageRank()
+	}
+	if opts.Clusters {
+		cg.cluster()
+	}
+}
+
+// Prune removes the nodes that are not connected to any edge, and are not a parent of any connected node.
+func (cg *CytoGraph) Prune() {
+	keep := make(map[CytoID]bool)
+	var mark func(id CytoID)
+	mark = func(id CytoID) {
+		if id == "" || keep[id] {
+			return
+		}
+		keep[id] = true
+		if n, ok := cg.Nodes[id]; ok {
+			mark(n.Data.Parent)
+		}
+	}
+	for _, e := range cg.Edges {
+		mark(e.Data.Source)
+		mark(e.Data.Target)
+	}
+	for id := range cg.Nodes {
+		if !keep[id] {
+			delete(cg.Nodes, id)
+		}
+	}
+	// forget removed elements, so they are created again if a call graph loaded later refers to them.
+	for name, id := range cg.idMap {
+		_, isNode := cg.Nodes[id]
+		_, isEdge := cg.Edges[id]
+		if !isNode && !isEdge {
+			delete(cg.idMap, name)
+		}
+	}
+}
+
+// callSites returns the positions of the call sites of the edge, as file:line, if known.
+func callSites(e *CytoEdge) []string {
+	if len(e.Data.Sites) > 0 || e.Data.File == "" {
+		return e.Data.Sites
+	}
+	return []string{e.Data.File + ":" + strconv.Itoa(e.Data.Line)}
+}
+
+// callCount returns the number of calls the edge stands for: its count if it is merged or aggregated, or else 1.
+func callCount(e *CytoEdge) int {
+	if e.Data.Count > 0 {
+		return e.Data.Count
+	}
+	return 1
+}
+
+// mergeCalls merges the parallel edges, of the calls from the same caller to the same callee at different call sites,
+// into the first edge, which counts the calls it stands for, lists their call sites, and has the classes of all of them.
+// The merged edge is dynamic, concurrent or deferred if any of the calls is. Edges that are not calls are kept as they are.
+func (cg *CytoGraph) mergeCalls() {
+	ids := make([]CytoID, 0, len(cg.Edges))
+	for id := range cg.Edges {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	first := make(map[[2]CytoID]*CytoEdge)
+	for _, id := range ids {
+		e := cg.Edges[id]
+		if !isCall(e) {
+			continue
+		}
+		key := [2]CytoID{e.Data.Source, e.Data.Target}
+		f, ok := first[key]
+		if !ok {
+			first[key] = e
+			continue
+		}
+		mergeEdge(f, e)
+		delete(cg.Edges, id)
+	}
+}
+
+// mergeEdge merges the call e into the call f between the same caller and callee: f counts the calls of both,
+// and lists their call sites. f is dynamic, concurrent or deferred if either call is, and has the classes of both.
+func mergeEdge(f *CytoEdge, e *CytoEdge) {
+	f.Data.Count = callCount(f) + callCount(e)
+	f.Data.Sites = append(callSites(f), callSites(e)...)
+	if e.Data.Kind == "dynamic" {
+		f.Data.Kind = "dynamic"
+	}
+	f.Data.Concurrent = f.Data.Concurrent || e.Data.Concurrent
+	f.Data.Deferred = f.Data.Deferred || e.Data.Deferred
+	for _, c := range e.Classes {
+		f.Classes = appendUnique(f.Classes, c)
+	}
+}
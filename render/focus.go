@@ -0,0 +1,96 @@
+package render
+
+import (
+	"sort"
+	"strings"
+)
+
+// shortName drops the package path, except the package name, from a fully qualified function name,
+// e.g. "(*example.com/pkg.Server).Serve" becomes "(*pkg.Server).Serve".
+func shortName(name string) string {
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return name
+	}
+	start := strings.LastIndexAny(name[:i], "(*") + 1
+	return name[:start] + name[i+1:]
+}
+
+// matchesName tells if the function node has the name, fully qualified, or with just the package name.
+func matchesName(n *CytoNode, name string) bool {
+	return n.Data.Name != "" && (n.Data.Name == name || shortName(n.Data.Name) == name)
+}
+
+// adjacency returns the callees and the callers of each node.
+// Edges that are not calls, like implementations and embeddings, are left out.
+// The callees and callers are sorted by ID, so the algorithms that follow them give the same result every time.
+func (cg *CytoGraph) adjacency() (out map[CytoID][]CytoID, in map[CytoID][]CytoID) {
+	out = make(map[CytoID][]CytoID)
+	in = make(map[CytoID][]CytoID)
+	for _, e := range cg.Edges {
+		if !isCall(e) {
+			continue
+		}
+		out[e.Data.Source] = append(out[e.Data.Source], e.Data.Target)
+		in[e.Data.Target] = append(in[e.Data.Target], e.Data.Source)
+	}
+	for _, adj := range []map[CytoID][]CytoID{out, in} {
+		for _, ids := range adj {
+			sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+		}
+	}
+	return out, in
+}
+
+// nodesNamed returns the function nodes with the name, fully qualified, or with just the package name.
+func (cg *CytoGraph) nodesNamed(name string) []CytoID {
+	var out []CytoID
+	for id, n := range cg.Nodes {
+		if matchesName(n, name) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// hopDistances returns the number of hops from the start nodes to each node reachable within maxHops.
+// There is no limit if maxHops is negative.
+func hopDistances(start []CytoID, adj map[CytoID][]CytoID, maxHops int) map[CytoID]int {
+	dist := make(map[CytoID]int)
+	for _, id := range start {
+		dist[id] = 0
+	}
+	frontier := start
+	for hop := 1; (maxHops < 0 || hop <= maxHops) && len(frontier) > 0; hop++ {
+		var next []CytoID
+		for _, id := range frontier {
+			for _, other := range adj[id] {
+				if _, ok := dist[other]; !ok {
+					dist[other] = hop
+					next = append(next, other)
+				}
+			}
+		}
+		frontier = next
+	}
+	return dist
+}
+
+// focus removes the edges that are not between the callers and callees within the given number of hops
+// of the focused functions.
+func (cg *CytoGraph) focus(focus string, hops int) {
+	out, in := cg.adjacency()
+	start := cg.nodesNamed(focus)
+	keep := make(map[CytoID]bool)
+	// callees and callers are searched separately: callers of callees are not related to the focus.
+	for _, adj := range []map[CytoID][]CytoID{out, in} {
+		for id := range hopDistances(start, adj, hops) {
+			keep[id] = true
+		}
+	}
+	for id, e := range cg.Edges {
+		if !keep[e.Data.Source] || !keep[e.Data.Target] {
+			delete(cg.Edges, id)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package render
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// addrGlobal returns the package-level variable the address is of, or of a field or element of, or nil if none.
+func addrGlobal(v ssa.Value) *ssa.Global {
+	for {
+		switch a := v.(type) {
+		case *ssa.Global:
+			return a
+		case *ssa.FieldAddr:
+			v = a.X
+		case *ssa.IndexAddr:
+			v = a.X
+		default:
+			return nil
+		}
+	}
+}
+
+// onlyStoredTo tells if the instruction is an address that is only used to store to, directly,
+// or through the addresses of its fields and elements.
+func onlyStoredTo(instr ssa.Instruction) bool {
+	v, ok := instr.(ssa.Value)
+	if !ok || v.Referrers() == nil || len(*v.Referrers()) == 0 {
+		return false
+	}
+	for _, ref := range *v.Referrers() {
+		switch r := ref.(type) {
+		case *ssa.Store:
+			if r.Addr != v {
+				return false
+			}
+		case *ssa.FieldAddr, *ssa.IndexAddr:
+			if !onlyStoredTo(r) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// globalAccesses returns the package-level variables the function reads, and writes to.
+// Stores to the variables, or to their fields or elements, are writes; any other use of a variable is a read.
+func globalAccesses(fn *ssa.Function) (reads map[*ssa.Global]bool, writes map[*ssa.Global]bool) {
+	reads, writes = make(map[*ssa.Global]bool), make(map[*ssa.Global]bool)
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if store, ok := instr.(*ssa.Store); ok {
+				if g := addrGlobal(store.Addr); g != nil {
+					writes[g] = true
+				}
+				if g, ok := store.Val.(*ssa.Global); ok {
+					reads[g] = true
+				}
+				continue
+			}
+			if onlyStoredTo(instr) {
+				continue
+			}
+			for _, op := range instr.Operands(nil) {
+				if g, ok := (*op).(*ssa.Global); ok {
+					reads[g] = true
+				}
+			}
+		}
+	}
+	return reads, writes
+}
+
+// processGlobal returns the ID of the node of the package-level variable, nested in its package,
+// creating it if it does not exist yet.
+func (cg *CytoGraph) processGlobal(g *ssa.Global) CytoID {
+	isNew, id := cg.GetID(fmt.Sprintf("global ~ %s", g.String()), true)
+	if !isNew {
+		return id
+	}
+	name := g.String()
+	n := newCytoNode(NodeData{
+		Id:          id,
+		Parent:      cg.ProcessPkg(g.Pkg.Pkg),
+		Label:       g.Name(),
+		Description: &name,
+	})
+	n.Data.Color = integersToColor(stringToIntHash(name)).Hex()
+	n.Classes = append(n.Classes, "global-var")
+	origin := cg.pkgOrigin(g.Pkg.Pkg.Path())
+	n.Classes = append(n.Classes, origin)
+	if origin == StdlibOrigin {
+		n.Classes = append(n.Classes, "go_root")
+	}
+	if !token.IsExported(g.Name()) {
+		n.Classes = append(n.Classes, "unexported")
+	}
+	cg.Nodes[id] = n
+	return id
+}
+
+// processGlobalAccesses adds edges, of the "reads" and "writes" classes, from the function node
+// to the nodes of the package-level variables the function reads and writes.
+func (cg *CytoGraph) processGlobalAccesses(fnID CytoID, fn *ssa.Function) {
+	reads, writes := globalAccesses(fn)
+	for _, access := range []struct {
+		class   string
+		globals map[*ssa.Global]bool
+	}{{"reads", reads}, {"writes", writes}} {
+		globals := make([]*ssa.Global, 0, len(access.globals))
+		for g := range access.globals {
+			// the init guard of packages is no state of the program
+			if g.Pkg != nil && g.Name() != "init$guard" {
+				globals = append(globals, g)
+			}
+		}
+		// the globals get their IDs in order of their name, for deterministic output
+		sort.Slice(globals, func(i, j int) bool { return globals[i].String() < globals[j].String() })
+		for _, g := range globals {
+			globalID := cg.processGlobal(g)
+			isNew, id := cg.GetID(fmt.Sprintf("%s ~ %s -> %s", access.class, fnID, g.String()), false)
+			if !isNew {
+				continue
+			}
+			e := newCytoEdge(EdgeData{Id: id, Source: fnID, Target: globalID})
+			e.Classes = append(e.Classes, access.class)
+			cg.Edges[id] = e
+		}
+	}
+}
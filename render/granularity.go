@@ -0,0 +1,224 @@
+package render
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ParseGranularity maps a granularity name, as used on the command line, to a granularity.
+func ParseGranularity(name string) (Granularity, error) {
+	switch g := Granularity(name); g {
+	case FunctionGranularity, FileGranularity, TypeGranularity, PackageGranularity:
+		return g, nil
+	}
+	return "", fmt.Errorf("granularity not recognized: %q", name)
+}
+
+// packageNode returns the package node the node is nested in, or nil if unknown.
+func (cg *CytoGraph) packageNode(n *CytoNode) *CytoNode {
+	for n != nil {
+		if n.HasClass("package") {
+			return n
+		}
+		if n.Data.Parent == "" {
+			return nil
+		}
+		n = cg.Nodes[n.Data.Parent]
+	}
+	return nil
+}
+
+// groupByFile nests the function nodes in a node of their source file, in their package.
+// Functions are labeled with their receiver type, as they are not nested in it anymore.
+// The type nodes that are left empty are removed by pruning.
+func (cg *CytoGraph) groupByFile() {
+	var funcs []*CytoNode
+	for _, n := range cg.Nodes {
+		if n.Data.Name != "" && n.Data.File != "" && !n.HasClass("file") {
+			funcs = append(funcs, n)
+		}
+	}
+	// file nodes get their IDs in the order of the functions, for deterministic output
+	sort.Slice(funcs, func(i, j int) bool { return lessID(funcs[i].Data.Id, funcs[j].Data.Id) })
+	for _, n := range funcs {
+		pkgNode := cg.packageNode(n)
+		if pkgNode == nil {
+			continue
+		}
+		if parent, ok := cg.Nodes[n.Data.Parent]; ok && parent.HasClass("file") {
+			continue
+		}
+		isNew, fileID := cg.GetID("file ~ "+n.Data.File, true)
+		if isNew {
+			file := n.Data.File
+			fileNode := newCytoNode(NodeData{
+				Id:          fileID,
+				Label:       filepath.Base(file),
+				Description: &file,
+				Parent:      pkgNode.Data.Id,
+			})
+			fileNode.Data.Color = integersToColor(stringToIntHash(file)).Hex()
+			fileNode.Classes = append(fileNode.Classes, "file")
+			cg.Nodes[fileID] = fileNode
+		}
+		n.Data.Parent = fileID
+		if pkgNode.Data.Description != nil {
+			n.Data.Label = strings.Replace(n.Data.Name, *pkgNode.Data.Description+".", "", -1)
+		}
+	}
+}
+
+// groupByPackage replaces the calls between functions with calls between their packages, counting the calls each stands for.
+// Calls within a package are left out, the package nodes count their calling and called functions instead.
+// The function and type nodes are removed by pruning, as no edge is connected to them anymore.
+func (cg *CytoGraph) groupByPackage() {
+	pkgOf := make(map[CytoID]*CytoNode)
+	for id, n := range cg.Nodes {
+		if n.Data.Name == "" || n.HasClass("package") {
+			continue
+		}
+		if pkgNode := cg.packageNode(n); pkgNode != nil {
+			pkgOf[id] = pkgNode
+		}
+	}
+	cg.aggregate(pkgOf, "package-call")
+}
+
+// groupByType replaces the calls between methods with calls between their receiver types, counting the calls each stands for.
+// The methods of T and *T are merged into the same type node. Functions without a receiver stand for themselves.
+// Calls within a type are left out, the type nodes count their calling and called methods instead.
+func (cg *CytoGraph) groupByType() {
+	funcs := make([]*CytoNode, 0, len(cg.Nodes))
+	for _, n := range cg.Nodes {
+		if n.Data.Name != "" {
+			funcs = append(funcs, n)
+		}
+	}
+	// the type node found first stands for both T and *T, for deterministic output
+	sort.Slice(funcs, func(i, j int) bool { return lessID(funcs[i].Data.Id, funcs[j].Data.Id) })
+	types := make(map[string]*CytoNode)
+	typeOf := make(map[CytoID]*CytoNode)
+	for _, n := range funcs {
+		recv, ok := cg.Nodes[n.Data.Parent]
+		if !ok || !recv.HasClass("type") {
+			typeOf[n.Data.Id] = n
+			continue
+		}
+		// the labels of the type nodes of T and *T are both T, in the same package
+		key := string(recv.Data.Parent) + " ~ " + recv.Data.Label
+		if t, ok := types[key]; ok {
+			recv = t
+		} else {
+			types[key] = recv
+		}
+		typeOf[n.Data.Id] = recv
+	}
+	cg.aggregate(typeOf, "type-call")
+}
+
+// aggregate replaces the calls between nodes with calls between the nodes they are grouped into, with the given class,
+// counting the calls each stands for. Calls within a group, and calls from or to nodes without group, are left out.
+// Group nodes count the nodes in them that call or are called, unless a node is its own group.
+// Calls aggregated before, with the same class, are kept, so a graph can be aggregated again after loading more calls.
+func (cg *CytoGraph) aggregate(groupOf map[CytoID]*CytoNode, class string) {
+	// aggregated calls get their IDs in the order of the first call between the groups, for deterministic output
+	ids := make([]CytoID, 0, len(cg.Edges))
+	for id := range cg.Edges {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	counted := make(map[CytoID]bool)
+	for _, id := range ids {
+		e := cg.Edges[id]
+		if e.HasClass(class) {
+			continue
+		}
+		delete(cg.Edges, id)
+		src, srcOk := groupOf[e.Data.Source]
+		dst, dstOk := groupOf[e.Data.Target]
+		if !srcOk || !dstOk {
+			continue
+		}
+		for _, f := range []CytoID{e.Data.Source, e.Data.Target} {
+			if group := groupOf[f]; !counted[f] && group.Data.Id != f {
+				counted[f] = true
+				group.Data.Count++
+			}
+		}
+		if src == dst {
+			continue
+		}
+		isNew, callID := cg.GetID(fmt.Sprintf("aggregated call ~ %s -> %s", src.Data.Id, dst.Data.Id), false)
+		if isNew {
+			call := newCytoEdge(EdgeData{Id: callID, Source: src.Data.Id, Target: dst.Data.Id})
+			call.Classes = append(call.Classes, class)
+			cg.Edges[callID] = call
+		}
+		cg.Edges[callID].Data.Count += callCount(e)
+	}
+}
+
+// nestPaths nests the package nodes in nodes of the segments of their import path, e.g. github.com > org > repo > pkg,
+// to group related packages. Each path node stands for an import path prefix, and is labeled with its last segment.
+func (cg *CytoGraph) nestPaths() {
+	var pkgs []*CytoNode
+	for _, n := range cg.Nodes {
+		// packages nested before, e.g. in a graph that is read back, keep their parent
+		if n.HasClass("package") && n.Data.Parent == "" && n.Data.Description != nil {
+			pkgs = append(pkgs, n)
+		}
+	}
+	// path nodes get their IDs in the order of the packages, for deterministic output
+	sort.Slice(pkgs, func(i, j int) bool { return lessID(pkgs[i].Data.Id, pkgs[j].Data.Id) })
+	var pathNode func(prefix string) CytoID
+	pathNode = func(prefix string) CytoID {
+		isNew, id := cg.GetID("path ~ "+prefix, true)
+		if !isNew {
+			return id
+		}
+		desc := prefix
+		n := newCytoNode(NodeData{Id: id, Label: prefix, Description: &desc})
+		if i := strings.LastIndex(prefix, "/"); i >= 0 {
+			n.Data.Label = prefix[i+1:]
+			n.Data.Parent = pathNode(prefix[:i])
+		}
+		n.Data.Color = integersToColor(stringToIntHash(prefix)).Hex()
+		n.Classes = append(n.Classes, "path")
+		cg.Nodes[id] = n
+		return id
+	}
+	for _, p := range pkgs {
+		if i := strings.LastIndex(*p.Data.Description, "/"); i >= 0 {
+			p.Data.Parent = pathNode((*p.Data.Description)[:i])
+		}
+	}
+}
+
+// nestModules nests the package nodes in a node of their module, labeled with the module path, and described
+// with its version. Packages without module, e.g. of the standard library, are not nested.
+func (cg *CytoGraph) nestModules() {
+	var pkgs []*CytoNode
+	for _, n := range cg.Nodes {
+		if n.HasClass("package") && n.Data.Parent == "" && n.Data.Module != "" {
+			pkgs = append(pkgs, n)
+		}
+	}
+	// module nodes get their IDs in the order of the packages, for deterministic output
+	sort.Slice(pkgs, func(i, j int) bool { return lessID(pkgs[i].Data.Id, pkgs[j].Data.Id) })
+	for _, p := range pkgs {
+		isNew, id := cg.GetID("module ~ "+p.Data.Module, true)
+		if isNew {
+			desc := p.Data.Module
+			if p.Data.Version != "" {
+				desc += " " + p.Data.Version
+			}
+			m := newCytoNode(NodeData{Id: id, Label: p.Data.Module, Description: &desc, Module: p.Data.Module, Version: p.Data.Version})
+			m.Data.Color = integersToColor(stringToIntHash(p.Data.Module)).Hex()
+			m.Classes = append(m.Classes, "module")
+			cg.Nodes[id] = m
+		}
+		p.Data.Parent = id
+	}
+}
@@ -0,0 +1,128 @@
+package render
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/static"
+	"golang.org/x/tools/go/ssa"
+)
+
+// testLib is a package imported by the programs of testPkgGraph.
+const testLib = `package lib
+
+func F() {}
+func G() {}
+`
+
+// testPkgGraph builds the static call graph of a program of a package with the path, and the example.com/lib package it imports.
+func testPkgGraph(t *testing.T, path, src string) *callgraph.Graph {
+	t.Helper()
+	fset := token.NewFileSet()
+	prog := ssa.NewProgram(fset, ssa.SanityCheckFunctions)
+	imported := make(map[string]*types.Package)
+	conf := &types.Config{Importer: testImporter(imported)}
+	for _, p := range []struct{ path, src string }{{"example.com/lib", testLib}, {path, src}} {
+		f, err := parser.ParseFile(fset, p.path+".go", p.src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info := &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Implicits:  make(map[ast.Node]types.Object),
+			Scopes:     make(map[ast.Node]*types.Scope),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		}
+		pkg, err := conf.Check(p.path, fset, []*ast.File{f}, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		imported[p.path] = pkg
+		prog.CreatePackage(pkg, []*ast.File{f}, info, true)
+	}
+	prog.Build()
+	return static.CallGraph(prog)
+}
+
+// testImporter imports the packages type checked before.
+type testImporter map[string]*types.Package
+
+func (imp testImporter) Import(path string) (*types.Package, error) {
+	if p, ok := imp[path]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("package %s not found", path)
+}
+
+func TestPackageGranularityMultipleLoads(t *testing.T) {
+	graphs := []*callgraph.Graph{
+		testPkgGraph(t, "example.com/one", `package one
+
+import "example.com/lib"
+
+func Run() { lib.F() }
+`),
+		testPkgGraph(t, "example.com/two", `package two
+
+import "example.com/lib"
+
+func Run() { lib.F(); lib.G() }
+`),
+	}
+	want := []string{"one->lib", "two->lib"}
+
+	t.Run("filtered once", func(t *testing.T) {
+		cg := NewCytoGraph()
+		opts := &RenderOptions{IncludeGoRoot: true, IncludeUnexported: true}
+		for _, g := range graphs {
+			if err := cg.LoadCallGraph(g, opts); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cg.Filter(&RenderOptions{IncludeGoRoot: true, IncludeUnexported: true, Granularity: PackageGranularity})
+		checkPkgCalls(t, cg, want, 3)
+	})
+	// aggregated calls of the first load are kept when the graph is aggregated again
+	t.Run("filtered after each load", func(t *testing.T) {
+		cg := NewCytoGraph()
+		opts := &RenderOptions{IncludeGoRoot: true, IncludeUnexported: true, Granularity: PackageGranularity}
+		for _, g := range graphs {
+			if err := cg.LoadCallGraph(g, opts); err != nil {
+				t.Fatal(err)
+			}
+			cg.Filter(opts)
+		}
+		checkPkgCalls(t, cg, want, 3)
+	})
+}
+
+// checkPkgCalls checks the package calls of the graph, and the number of calls they stand for in total.
+func checkPkgCalls(t *testing.T, cg *CytoGraph, want []string, calls int) {
+	t.Helper()
+	got := testEdges(cg)
+	if len(got) != len(want) {
+		t.Fatalf("got package calls %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got package calls %v, want %v", got, want)
+		}
+	}
+	total := 0
+	for _, e := range cg.Edges {
+		if !e.HasClass("package-call") {
+			t.Errorf("edge %s is not a package call", e.Data.Id)
+		}
+		total += callCount(e)
+	}
+	if total != calls {
+		t.Errorf("got %d calls in total, want %d", total, calls)
+	}
+}
@@ -0,0 +1,97 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testGraph builds a graph of functions of the package example.com/pkg, with the calls written as "caller->callee".
+// The functions are named pkg.<name>, and labeled with just their name.
+func testGraph(calls ...string) *CytoGraph {
+	cg := NewCytoGraph()
+	desc := "example.com/pkg"
+	_, pkgID := cg.GetID("pkg ~ "+desc, true)
+	pkg := newCytoNode(NodeData{Id: pkgID, Label: "pkg", Description: &desc})
+	pkg.Classes = append(pkg.Classes, "package")
+	cg.Nodes[pkgID] = pkg
+	for i, c := range calls {
+		ends := strings.Split(c, "->")
+		src, dst := testFunc(cg, ends[0]), testFunc(cg, ends[1])
+		_, id := cg.GetID(fmt.Sprintf("call %d ~ %s", i, c), false)
+		cg.Edges[id] = newCytoEdge(EdgeData{Id: id, Source: src.Data.Id, Target: dst.Data.Id})
+	}
+	return cg
+}
+
+// testFunc returns the node of the function of the test graph with the name, added if it is not in the graph yet.
+func testFunc(cg *CytoGraph, name string) *CytoNode {
+	isNew, id := cg.GetID("func ~ pkg."+name, true)
+	if !isNew {
+		return cg.Nodes[id]
+	}
+	n := newCytoNode(NodeData{Id: id, Name: "pkg." + name, Label: name, Parent: cg.idMap["pkg ~ example.com/pkg"],
+		Signature: "func()", File: "pkg.go", Line: int(cg.idCounter)})
+	cg.Nodes[id] = n
+	return n
+}
+
+// testLabel returns the label of the node, or the ID if the node is not in the graph.
+func testLabel(cg *CytoGraph, id CytoID) string {
+	if n, ok := cg.Nodes[id]; ok {
+		return n.Data.Label
+	}
+	return string(id)
+}
+
+// testEdges lists the edges of the graph as "source->target" labels, sorted.
+func testEdges(cg *CytoGraph) []string {
+	var out []string
+	for _, e := range cg.Edges {
+		out = append(out, testLabel(cg, e.Data.Source)+"->"+testLabel(cg, e.Data.Target))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestDeterministicOutput(t *testing.T) {
+	var calls []string
+	for i := 0; i < 40; i++ {
+		calls = append(calls, fmt.Sprintf("f%d->f%d", i, (i*7+3)%40), fmt.Sprintf("f%d->f%d", i, (i*13+5)%40),
+			fmt.Sprintf("f%d->f%d", i, (i*7+3)%40), fmt.Sprintf("f%d->sink%d", i, i%3))
+	}
+	cases := []struct {
+		name string
+		opts RenderOptions
+	}{
+		{"plain", RenderOptions{}},
+		{"metrics", RenderOptions{MergeCalls: true, Betweenness: true, Rank: true, Clusters: true}},
+		{"condense", RenderOptions{Condense: true, Rank: true}},
+		{"dominators", RenderOptions{Dominators: true, NestPaths: true}},
+		{"file", RenderOptions{Granularity: FileGranularity, Rank: true}},
+		{"package", RenderOptions{Granularity: PackageGranularity, MergeCalls: true}},
+		{"focus", RenderOptions{Focus: "pkg.f3", FocusHops: 2, Betweenness: true, BetweennessSamples: 10}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var first []byte
+			for i := 0; i < 5; i++ {
+				cg := testGraph(calls...)
+				opts := c.opts
+				cg.Filter(&opts)
+				cg.SetSortOrder(FanInOrder)
+				var buf bytes.Buffer
+				if err := cg.WriteJson(&buf); err != nil {
+					t.Fatal(err)
+				}
+				if i == 0 {
+					first = buf.Bytes()
+				} else if !bytes.Equal(first, buf.Bytes()) {
+					t.Fatalf("output %d differs from the first output:\n%s\n%s", i, first, buf.Bytes())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+)
+
+// SymbolMatch is a function found by Grep, with its position and the numbers of calls to and from it.
+type SymbolMatch struct {
+	Func    string `json:"func"`
+	Package string `json:"package"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	FanIn   int    `json:"fan_in"`
+	FanOut  int    `json:"fan_out"`
+}
+
+// Grep returns the function nodes of which the fully qualified name, receiver type name, or package path matches,
+// sorted by position.
+func (cg *CytoGraph) Grep(re *regexp.Regexp) []SymbolMatch {
+	cg.countFans()
+	var out []SymbolMatch
+	for _, n := range cg.Nodes {
+		if n.Data.Name == "" {
+			continue
+		}
+		pkg := cg.nodePackage(n)
+		recv := ""
+		if parent, ok := cg.Nodes[n.Data.Parent]; ok && parent.HasClass("type") {
+			recv = parent.Data.Label
+		}
+		if !re.MatchString(n.Data.Name) && !re.MatchString(pkg) && (recv == "" || !re.MatchString(recv)) {
+			continue
+		}
+		out = append(out, SymbolMatch{
+			Func:    n.Data.Name,
+			Package: pkg,
+			File:    n.Data.File,
+			Line:    n.Data.Line,
+			FanIn:   n.Data.FanIn,
+			FanOut:  n.Data.FanOut,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		if out[i].Line != out[j].Line {
+			return out[i].Line < out[j].Line
+		}
+		return out[i].Func < out[j].Func
+	})
+	return out
+}
+
+// WriteSymbolMatchesText writes each match on a line, prefixed with its position, and followed by its fan-in and fan-out.
+func WriteSymbolMatchesText(w io.Writer, matches []SymbolMatch) error {
+	for _, m := range matches {
+		if _, err := fmt.Fprintf(w, "%s:%d: %s (in %d, out %d)\n", m.File, m.Line, m.Func, m.FanIn, m.FanOut); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// recvNode returns the ID of the receiver type node of the type, named or a pointer to a named type,
+// if it is in the graph.
+func (cg *CytoGraph) recvNode(t types.Type) (CytoID, bool) {
+	named, ok := t.(*types.Named)
+	if ptr, isPtr := t.(*types.Pointer); isPtr {
+		named, ok = ptr.Elem().(*types.Named)
+	}
+	if !ok || named.Obj().Pkg() == nil {
+		return "", false
+	}
+	id, ok := cg.idMap[fmt.Sprintf("recv ~ %s ~ %s", named.Obj().Pkg().Path(), t.String())]
+	if !ok {
+		return "", false
+	}
+	_, ok = cg.Nodes[id]
+	return id, ok
+}
+
+// AddImplementations adds the interfaces as nodes, of the "interface" class, in their package, with an edge,
+// of the "implements" class, from each receiver type node in the graph that implements it.
+// The receiver type nodes of both T and *T are linked, if T implements the interface.
+// Interfaces that no type in the graph implements are left out.
+func (cg *CytoGraph) AddImplementations(impls map[*types.Named][]types.Type) {
+	ifaces := make([]*types.Named, 0, len(impls))
+	for iface := range impls {
+		ifaces = append(ifaces, iface)
+	}
+	// interfaces are added in order of their name, for deterministic output
+	sort.Slice(ifaces, func(i, j int) bool { return ifaces[i].String() < ifaces[j].String() })
+	for _, iface := range ifaces {
+		var typeIDs []CytoID
+		for _, t := range impls[iface] {
+			if id, ok := cg.recvNode(t); ok {
+				typeIDs = append(typeIDs, id)
+			}
+			// methods with value receivers are in the method set of the pointer type too
+			if _, isPtr := t.(*types.Pointer); !isPtr {
+				if id, ok := cg.recvNode(types.NewPointer(t)); ok {
+					typeIDs = append(typeIDs, id)
+				}
+			}
+		}
+		if len(typeIDs) == 0 {
+			continue
+		}
+		sort.Slice(typeIDs, func(i, j int) bool { return lessID(typeIDs[i], typeIDs[j]) })
+		ifaceID := cg.processNamed(iface)
+		for _, typeID := range typeIDs {
+			isNew, id := cg.GetID(fmt.Sprintf("implements ~ %s -> %s", typeID, ifaceID), false)
+			if !isNew {
+				continue
+			}
+			e := newCytoEdge(EdgeData{Id: id, Source: typeID, Target: ifaceID})
+			e.Classes = append(e.Classes, "implements")
+			cg.Edges[id] = e
+		}
+	}
+}
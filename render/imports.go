@@ -0,0 +1,43 @@
+package render
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// LoadImportGraph adds the packages, and the packages they import transitively, as package nodes,
+// with an edge, of the "import" class, from each package to each of its imports.
+// Packages of the Go root are left out, unless included by the options. The graph is filtered with the options afterwards.
+func (cg *CytoGraph) LoadImportGraph(pkgs []*types.Package, opts *RenderOptions) {
+	cg.opts = opts
+	seen := make(map[*types.Package]bool)
+	var visit func(p *types.Package)
+	visit = func(p *types.Package) {
+		if seen[p] {
+			return
+		}
+		seen[p] = true
+		imports := append([]*types.Package(nil), p.Imports()...)
+		sort.Slice(imports, func(i, j int) bool { return imports[i].Path() < imports[j].Path() })
+		for _, imp := range imports {
+			if !opts.IncludeGoRoot && cg.pkgOrigin(imp.Path()) == StdlibOrigin {
+				continue
+			}
+			src, dst := cg.ProcessPkg(p), cg.ProcessPkg(imp)
+			isNew, id := cg.GetID(fmt.Sprintf("import ~ %s -> %s", p.Path(), imp.Path()), false)
+			if isNew {
+				e := newCytoEdge(EdgeData{Id: id, Source: src, Target: dst})
+				e.Classes = append(e.Classes, "import")
+				cg.Edges[id] = e
+			}
+			visit(imp)
+		}
+	}
+	sorted := append([]*types.Package(nil), pkgs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path() < sorted[j].Path() })
+	for _, p := range sorted {
+		visit(p)
+	}
+	cg.Filter(opts)
+}
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"sort"
+	"strings"
+)
+
+// instantiation splits the name of an instantiation of a generic function or method into the name of its origin,
+// and its type arguments in brackets, e.g. "example.com/pkg.Map" and "[int string]" for "example.com/pkg.Map[int string]",
+// or "(*example.com/pkg.List).Push" and "[int]" for "(*example.com/pkg.List[int]).Push".
+// ok is false if the name has no type arguments.
+func instantiation(name string) (origin string, typeArgs string, ok bool) {
+	var o, args strings.Builder
+	depth := 0
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+			if depth == 0 {
+				args.WriteByte(c)
+				continue
+			}
+		}
+		if depth > 0 {
+			args.WriteByte(c)
+		} else {
+			o.WriteByte(c)
+		}
+	}
+	if args.Len() == 0 {
+		return name, "", false
+	}
+	return o.String(), args.String(), true
+}
+
+// foldInstantiations merges the function nodes of the instantiations of the same generic function or method
+// into one node, named after the generic function, with the "instantiations" class. The calls from and to
+// the folded nodes are merged per caller and callee, like merged calls, and list the type arguments of the
+// instantiations of the callee, or of the caller if the callee is not generic, in their type arguments.
+func (cg *CytoGraph) foldInstantiations() {
+	funcs := make([]*CytoNode, 0, len(cg.Nodes))
+	for _, n := range cg.Nodes {
+		if n.Data.Name != "" {
+			funcs = append(funcs, n)
+		}
+	}
+	// the instantiation found first stands for all of them, for deterministic output
+	sort.Slice(funcs, func(i, j int) bool { return lessID(funcs[i].Data.Id, funcs[j].Data.Id) })
+	origins := make(map[string]*CytoNode)
+	folded := make(map[CytoID]*CytoNode)
+	typeArgs := make(map[CytoID]string)
+	for _, n := range funcs {
+		origin, args, ok := instantiation(n.Data.Name)
+		if !ok {
+			continue
+		}
+		rep, ok := origins[origin]
+		if !ok {
+			rep = n
+			origins[origin] = n
+			n.Data.Name = origin
+			n.Data.Label, _, _ = instantiation(n.Data.Label)
+			n.Classes = appendUnique(n.Classes, "instantiations")
+		}
+		folded[n.Data.Id] = rep
+		typeArgs[n.Data.Id] = args
+	}
+	if len(folded) == 0 {
+		return
+	}
+
+	ids := make([]CytoID, 0, len(cg.Edges))
+	for id := range cg.Edges {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	first := make(map[[2]CytoID]*CytoEdge)
+	for _, id := range ids {
+		e := cg.Edges[id]
+		src, srcFolded := folded[e.Data.Source]
+		dst, dstFolded := folded[e.Data.Target]
+		if !isCall(e) || (!srcFolded && !dstFolded) {
+			continue
+		}
+		args := typeArgs[e.Data.Target]
+		if !dstFolded {
+			args = typeArgs[e.Data.Source]
+		}
+		if srcFolded {
+			e.Data.Source = src.Data.Id
+		}
+		if dstFolded {
+			e.Data.Target = dst.Data.Id
+		}
+		key := [2]CytoID{e.Data.Source, e.Data.Target}
+		f, ok := first[key]
+		if !ok {
+			first[key] = e
+			e.Data.TypeArgs = appendUnique(nil, args)
+			continue
+		}
+		mergeEdge(f, e)
+		f.Data.TypeArgs = appendUnique(f.Data.TypeArgs, args)
+		delete(cg.Edges, id)
+	}
+	for _, e := range first {
+		sort.Strings(e.Data.TypeArgs)
+	}
+}
@@ -0,0 +1,61 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInstantiation(t *testing.T) {
+	cases := []struct {
+		name     string
+		origin   string
+		typeArgs string
+	}{
+		{"example.com/pkg.Map", "example.com/pkg.Map", ""},
+		{"example.com/pkg.Map[int string]", "example.com/pkg.Map", "[int string]"},
+		{"example.com/pkg.Keys[map[string]int]", "example.com/pkg.Keys", "[map[string]int]"},
+		{"(*example.com/pkg.List[int]).Push", "(*example.com/pkg.List).Push", "[int]"},
+		{"(*example.com/pkg.List[int]).Map[string]", "(*example.com/pkg.List).Map", "[int][string]"},
+		{"example.com/pkg.Map[int]$1", "example.com/pkg.Map$1", "[int]"},
+	}
+	for _, c := range cases {
+		origin, typeArgs, ok := instantiation(c.name)
+		if origin != c.origin || typeArgs != c.typeArgs || ok != (c.typeArgs != "") {
+			t.Errorf("%s: got origin %q with type arguments %q (%v), expected %q with %q", c.name, origin, typeArgs, ok, c.origin, c.typeArgs)
+		}
+	}
+}
+
+func TestFoldInstantiations(t *testing.T) {
+	cg := testGraph("a->Map[int]", "a->Map[string]", "b->Map[int]", "Map[int]->c", "Map[string]->c", "a->c")
+	cg.Filter(&RenderOptions{FoldInstantiations: true})
+
+	if got, want := testEdges(cg), []string{"Map->c", "a->Map", "a->c", "b->Map"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got edges %v, expected %v", got, want)
+	}
+	funcs := cg.funcsByName()
+	if len(funcs) != 4 || funcs["pkg.Map"] == nil || !funcs["pkg.Map"].HasClass("instantiations") {
+		t.Fatalf("expected the instantiations folded into pkg.Map, got %v", funcs)
+	}
+	cases := []struct {
+		caller, callee string
+		count          int
+		typeArgs       []string
+	}{
+		{"a", "Map", 2, []string{"[int]", "[string]"}},
+		{"b", "Map", 0, []string{"[int]"}},
+		{"Map", "c", 2, []string{"[int]", "[string]"}},
+		{"a", "c", 0, nil},
+	}
+	for _, c := range cases {
+		for _, e := range cg.Edges {
+			if testLabel(cg, e.Data.Source) != c.caller || testLabel(cg, e.Data.Target) != c.callee {
+				continue
+			}
+			if e.Data.Count != c.count || !reflect.DeepEqual(e.Data.TypeArgs, c.typeArgs) {
+				t.Errorf("%s->%s: got count %d and type arguments %v, expected %d and %v",
+					c.caller, c.callee, e.Data.Count, e.Data.TypeArgs, c.count, c.typeArgs)
+			}
+		}
+	}
+}
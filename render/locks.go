@@ -0,0 +1,54 @@
+package render
+
+import (
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// lockMethods are the methods of sync.Mutex and sync.RWMutex that acquire or release the lock.
+var lockMethods = map[string]bool{"Lock": true, "Unlock": true, "RLock": true, "RUnlock": true, "TryLock": true, "TryRLock": true}
+
+// lockName describes the mutex at the address: the struct type and field name for fields, e.g. "example.com/pkg.Server.mu",
+// the name for package-level variables, or else just the type.
+func lockName(addr ssa.Value) string {
+	switch a := addr.(type) {
+	case *ssa.FieldAddr:
+		if ptr, ok := a.X.Type().Underlying().(*types.Pointer); ok {
+			if st, ok := ptr.Elem().Underlying().(*types.Struct); ok {
+				return types.TypeString(ptr.Elem(), nil) + "." + st.Field(a.Field).Name()
+			}
+		}
+	case *ssa.Global:
+		return a.String()
+	}
+	return addr.Type().String()
+}
+
+// funcLocks returns the mutexes the function locks or unlocks, sorted by name, directly or with defer statements.
+func funcLocks(fn *ssa.Function) []string {
+	var locks []string
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			site, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			callee := site.Common().StaticCallee()
+			if callee == nil || callee.Pkg == nil || callee.Pkg.Pkg.Path() != "sync" || !lockMethods[callee.Name()] {
+				continue
+			}
+			recv := callee.Signature.Recv()
+			if recv == nil || len(site.Common().Args) == 0 {
+				continue
+			}
+			if t := recv.Type().String(); t != "*sync.Mutex" && t != "*sync.RWMutex" {
+				continue
+			}
+			locks = appendUnique(locks, lockName(site.Common().Args[0]))
+		}
+	}
+	sort.Strings(locks)
+	return locks
+}
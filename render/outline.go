@@ -0,0 +1,53 @@
+package render
+
+import "sort"
+
+// OutlineFunc is a function, with the functions it calls and is called by.
+type OutlineFunc struct {
+	Name    string
+	Callers []string
+	Callees []string
+}
+
+// OutlinePackage is a package, with its functions.
+type OutlinePackage struct {
+	Path  string
+	Funcs []OutlineFunc
+}
+
+// Outline lists the function nodes per package, with their callers and callees, as a text alternative of the graph.
+// Packages, callers and callees are sorted by name, functions by name, or in the sort order of the graph if one is set.
+func (cg *CytoGraph) Outline() []OutlinePackage {
+	out, in := cg.adjacency()
+	names := func(ids []CytoID) []string {
+		seen := make(map[string]bool)
+		var list []string
+		for _, id := range ids {
+			if n, ok := cg.Nodes[id]; ok && !seen[displayName(n)] {
+				seen[displayName(n)] = true
+				list = append(list, displayName(n))
+			}
+		}
+		sort.Strings(list)
+		return list
+	}
+	var nodes []*CytoNode
+	for _, n := range cg.Nodes {
+		if n.Data.Name != "" {
+			nodes = append(nodes, n)
+		}
+	}
+	cg.sortListed(nodes)
+	byPkg := make(map[string][]OutlineFunc)
+	for _, n := range nodes {
+		pkg := cg.nodePackage(n)
+		id := n.Data.Id
+		byPkg[pkg] = append(byPkg[pkg], OutlineFunc{Name: n.Data.Name, Callers: names(in[id]), Callees: names(out[id])})
+	}
+	pkgs := make([]OutlinePackage, 0, len(byPkg))
+	for path, funcs := range byPkg {
+		pkgs = append(pkgs, OutlinePackage{Path: path, Funcs: funcs})
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Path < pkgs[j].Path })
+	return pkgs
+}
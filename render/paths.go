@@ -0,0 +1,164 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// KeepPaths removes the edges that are not on a call path from the functions named from, to the functions named to,
+// and then prunes the graph. Functions are named fully qualified, or with just the package name, e.g. main.main.
+// The start and end of the paths are marked with the path_source and path_target classes.
+// Returns false if there is no path, the graph is empty then.
+func (cg *CytoGraph) KeepPaths(from string, to string) bool {
+	out, in := cg.adjacency()
+	sources := cg.nodesNamed(from)
+	targets := cg.nodesNamed(to)
+	// a node is on a path if it is reachable from a source, and can reach a target.
+	reached := reachable(sources, out)
+	reaching := reachable(targets, in)
+	found := false
+	for id, e := range cg.Edges {
+		if reached[e.Data.Source] && reaching[e.Data.Target] {
+			found = true
+		} else {
+			delete(cg.Edges, id)
+		}
+	}
+	cg.Prune()
+	for _, id := range sources {
+		if n, ok := cg.Nodes[id]; ok {
+			n.Classes = append(n.Classes, "path_source")
+		}
+	}
+	for _, id := range targets {
+		if n, ok := cg.Nodes[id]; ok {
+			n.Classes = append(n.Classes, "path_target")
+		}
+	}
+	return found
+}
+
+// reachable returns the nodes reachable from the start nodes, including the start nodes.
+func reachable(start []CytoID, adj map[CytoID][]CytoID) map[CytoID]bool {
+	seen := make(map[CytoID]bool)
+	stack := append([]CytoID(nil), start...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		stack = append(stack, adj[id]...)
+	}
+	return seen
+}
+
+// EnumeratePaths finds the simple call paths, that call no function twice, from the functions named from,
+// to the functions named to, of at most maxLen calls. At most limit paths are returned, if limit is not 0.
+// Paths are sequences of node IDs, and are found in the order functions are listed in: by name, or in the sort order
+// of the graph if one is set.
+// The graph is reduced to the calls on the found paths, marked with the path class, and then pruned.
+func (cg *CytoGraph) EnumeratePaths(from string, to string, maxLen int, limit int) [][]CytoID {
+	// search in the graph of the paths of any length: no dead ends.
+	if !cg.KeepPaths(from, to) {
+		return nil
+	}
+	adj := make(map[CytoID][]CytoID)
+	seenPair := make(map[[2]CytoID]bool)
+	for _, e := range cg.Edges {
+		pair := [2]CytoID{e.Data.Source, e.Data.Target}
+		if !seenPair[pair] {
+			seenPair[pair] = true
+			adj[pair[0]] = append(adj[pair[0]], pair[1])
+		}
+	}
+	order := cg.listOrder()
+	listed := func(ids []CytoID) {
+		sort.Slice(ids, func(i, j int) bool { return order[ids[i]] < order[ids[j]] })
+	}
+	for _, ids := range adj {
+		listed(ids)
+	}
+	sources := cg.nodesNamed(from)
+	listed(sources)
+	targets := make(map[CytoID]bool)
+	for _, id := range cg.nodesNamed(to) {
+		targets[id] = true
+	}
+
+	var paths [][]CytoID
+	onPath := make(map[CytoID]bool)
+	var path []CytoID
+	var search func(id CytoID)
+	search = func(id CytoID) {
+		if limit != 0 && len(paths) >= limit {
+			return
+		}
+		path = append(path, id)
+		onPath[id] = true
+		if targets[id] && len(path) > 1 {
+			paths = append(paths, append([]CytoID(nil), path...))
+		} else if len(path) <= maxLen {
+			for _, next := range adj[id] {
+				if !onPath[next] {
+					search(next)
+				}
+			}
+		}
+		onPath[id] = false
+		path = path[:len(path)-1]
+	}
+	for _, id := range sources {
+		search(id)
+	}
+
+	onPaths := make(map[[2]CytoID]bool)
+	for _, p := range paths {
+		for i := 1; i < len(p); i++ {
+			onPaths[[2]CytoID{p[i-1], p[i]}] = true
+		}
+	}
+	for id, e := range cg.Edges {
+		if onPaths[[2]CytoID{e.Data.Source, e.Data.Target}] {
+			e.Classes = append(e.Classes, "path")
+		} else {
+			delete(cg.Edges, id)
+		}
+	}
+	cg.Prune()
+	return paths
+}
+
+// WritePathsText writes each path on a line, as the names of the functions, separated by arrows.
+// If src is not nil, each path is followed by the positions and source lines of its calls, indented.
+func (cg *CytoGraph) WritePathsText(w io.Writer, paths [][]CytoID, src *SourceLines) error {
+	var sites map[[2]CytoID]*CytoEdge
+	if src != nil {
+		sites = cg.firstCallSites()
+	}
+	for _, p := range paths {
+		names := make([]string, 0, len(p))
+		for _, id := range p {
+			if n, ok := cg.Nodes[id]; ok {
+				names = append(names, n.Data.Name)
+			}
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(names, " -> ")); err != nil {
+			return err
+		}
+		if src == nil {
+			continue
+		}
+		for i := 1; i < len(p); i++ {
+			if line := sourceContext(src, sites[[2]CytoID{p[i-1], p[i]}]); line != "" {
+				if _, err := fmt.Fprintf(w, "    %s\n", line); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnumeratePaths(t *testing.T) {
+	calls := []string{"a->b", "a->c", "a->d", "b->d", "c->d", "b->c", "d->e", "x->a"}
+	cases := []struct {
+		name   string
+		from   string
+		to     string
+		maxLen int
+		limit  int
+		paths  []string
+	}{
+		{"all", "pkg.a", "pkg.d", 3, 0, []string{"a b c d", "a b d", "a c d", "a d"}},
+		{"max length", "pkg.a", "pkg.d", 2, 0, []string{"a b d", "a c d", "a d"}},
+		{"direct", "pkg.a", "pkg.d", 1, 0, []string{"a d"}},
+		{"limit", "pkg.a", "pkg.d", 3, 2, []string{"a b c d", "a b d"}},
+		{"too long", "pkg.x", "pkg.e", 2, 0, nil},
+		{"longer", "pkg.x", "pkg.e", 3, 0, []string{"x a d e"}},
+		{"none", "pkg.e", "pkg.a", 3, 0, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cg := testGraph(calls...)
+			var got []string
+			onPaths := make(map[string]bool)
+			for _, p := range cg.EnumeratePaths(c.from, c.to, c.maxLen, c.limit) {
+				var names []string
+				for i, id := range p {
+					names = append(names, testLabel(cg, id))
+					if i > 0 {
+						onPaths[testLabel(cg, p[i-1])+"->"+testLabel(cg, id)] = true
+					}
+				}
+				got = append(got, strings.Join(names, " "))
+			}
+			if !reflect.DeepEqual(got, c.paths) {
+				t.Errorf("got paths %v, expected %v", got, c.paths)
+			}
+			// only the calls on the paths are kept
+			for _, e := range testEdges(cg) {
+				if !onPaths[e] {
+					t.Errorf("call %s is kept, but is not on a path", e)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package render
+
+import "golang.org/x/tools/go/ssa"
+
+// isPluginLoad tells if the function is plugin.Open or (*plugin.Plugin).Lookup, which load code at run time,
+// of which the callees cannot be resolved statically.
+func isPluginLoad(fn *ssa.Function) bool {
+	return fn.Pkg != nil && fn.Pkg.Pkg.Path() == "plugin" && (fn.Name() == "Open" || fn.Name() == "Lookup")
+}
+
+// loadsPlugins tells if the function opens plugins, or looks up their symbols.
+func loadsPlugins(fn *ssa.Function) bool {
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			site, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			if callee := site.Common().StaticCallee(); callee != nil && isPluginLoad(callee) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package render
+
+import "sync"
+
+// Nodes and edges are recycled between graphs, to keep the memory use of long-running processes,
+// which render many graphs, stable.
+var (
+	nodePool = sync.Pool{New: func() interface{} { return new(CytoNode) }}
+	edgePool = sync.Pool{New: func() interface{} { return new(CytoEdge) }}
+)
+
+func newCytoNode(data NodeData) *CytoNode {
+	n := nodePool.Get().(*CytoNode)
+	n.Data = data
+	return n
+}
+
+func newCytoEdge(data EdgeData) *CytoEdge {
+	e := edgePool.Get().(*CytoEdge)
+	e.Data = data
+	return e
+}
+
+// Release empties the graph, and recycles its nodes and edges for graphs created afterwards.
+// Nodes and edges of the graph must not be used anymore after releasing it, the graph itself can be reused.
+func (cg *CytoGraph) Release() {
+	for id, n := range cg.Nodes {
+		*n = CytoNode{Classes: n.Classes[:0]}
+		nodePool.Put(n)
+		delete(cg.Nodes, id)
+	}
+	for id, e := range cg.Edges {
+		*e = CytoEdge{Classes: e.Classes[:0]}
+		edgePool.Put(e)
+		delete(cg.Edges, id)
+	}
+	for name := range cg.idMap {
+		delete(cg.idMap, name)
+	}
+	for name := range cg.Reports {
+		delete(cg.Reports, name)
+	}
+	cg.idCounter = 0
+	cg.opts = &RenderOptions{}
+	cg.Meta = GraphMeta{}
+}
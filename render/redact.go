@@ -0,0 +1,104 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// pseudonym returns a stable replacement of the identifier: the same identifier always gets the same pseudonym.
+func pseudonym(kind string, name string) string {
+	if name == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(kind + " " + name))
+	return kind + "_" + hex.EncodeToString(sum[:6])
+}
+
+func pseudonyms(kind string, names []string) []string {
+	if names == nil {
+		return nil
+	}
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = pseudonym(kind, n)
+	}
+	return out
+}
+
+// Redact replaces the names of functions, types, packages, files, modules and local directories with stable pseudonyms,
+// so the graph can be shared without revealing identifiers. The structure, metrics and classes are kept.
+// Signatures are removed, and so are the reports, which name functions throughout, and the errors in the metadata.
+func (cg *CytoGraph) Redact() {
+	for _, n := range cg.Nodes {
+		d := &n.Data
+		switch {
+		case d.Name != "":
+			d.Label = pseudonym("func", d.Name)
+			d.Name = d.Label
+		case n.HasClass("package") && d.Description != nil:
+			d.Label = pseudonym("pkg", *d.Description)
+		case n.HasClass("file") && d.Description != nil:
+			d.Label = pseudonym("file", *d.Description)
+		case n.HasClass("module"):
+			d.Label = pseudonym("module", d.Module)
+		case n.HasClass("path") && d.Description != nil:
+			d.Label = pseudonym("path", *d.Description)
+		case (n.HasClass("interface") || n.HasClass("struct")) && d.Description != nil:
+			d.Label = pseudonym("type", *d.Description)
+		case n.HasClass("type"):
+			d.Label = pseudonym("type", string(d.Parent)+" "+d.Label)
+		default:
+			d.Label = pseudonym("node", d.Label)
+		}
+		if d.Description != nil {
+			desc := d.Label
+			d.Description = &desc
+		}
+		d.Signature = ""
+		d.File = pseudonym("file", d.File)
+		d.SourceURL = ""
+		d.DocURL = ""
+		d.Module = pseudonym("module", d.Module)
+		// modules replaced by a local directory list its path as version
+		if strings.HasPrefix(d.Version, "=> ") {
+			d.Version = "=> " + pseudonym("dir", strings.TrimPrefix(d.Version, "=> "))
+		}
+		d.Entrypoints = pseudonyms("entrypoint", d.Entrypoints)
+		d.Callbacks = pseudonyms("callback", d.Callbacks)
+		d.FeatureFlags = pseudonyms("func", d.FeatureFlags)
+		d.Repos = pseudonyms("repo", d.Repos)
+		d.Locks = pseudonyms("lock", d.Locks)
+		if d.Packages != nil {
+			pkgs := make(map[string]int)
+			for p, count := range d.Packages {
+				pkgs[pseudonym("pkg", p)] = count
+			}
+			d.Packages = pkgs
+		}
+	}
+	for _, e := range cg.Edges {
+		e.Data.File = pseudonym("file", e.Data.File)
+		e.Data.Sites = nil
+		if e.Data.Method != "" {
+			e.Data.Method = pseudonym("func", e.Data.Method)
+		}
+		e.Data.Candidates = pseudonyms("func", e.Data.Candidates)
+		e.Data.TypeArgs = pseudonyms("type", e.Data.TypeArgs)
+		if e.Data.Flag != "" {
+			e.Data.Flag = pseudonym("flag", e.Data.Flag)
+		}
+	}
+	cg.Reports = make(map[string]interface{})
+	cg.Meta.Packages = pseudonyms("pkg", cg.Meta.Packages)
+	cg.Meta.Repos = pseudonyms("repo", cg.Meta.Repos)
+	// errors name packages, files and functions: only the analyses that failed are kept
+	if cg.Meta.PartialReason != "" {
+		cg.Meta.PartialReason = "redacted"
+	}
+	for i, f := range cg.Meta.Fallbacks {
+		if j := strings.Index(f, ": "); j >= 0 {
+			cg.Meta.Fallbacks[i] = f[:j] + ": redacted"
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cases := []struct {
+		name    string
+		classes []string
+		data    NodeData
+		label   string
+	}{
+		{"function", nil, NodeData{Name: "pkg.Secret", Label: "Secret", Signature: "func(key string)"}, pseudonym("func", "pkg.Secret")},
+		{"package", []string{"package"}, NodeData{Label: "secret", Description: str("example.com/secret")}, pseudonym("pkg", "example.com/secret")},
+		{"file", []string{"file"}, NodeData{Label: "secret.go", Description: str("secret/secret.go")}, pseudonym("file", "secret/secret.go")},
+		{"module", []string{"module"}, NodeData{Label: "example.com/secret@v1.0.0", Module: "example.com/secret"}, pseudonym("module", "example.com/secret")},
+		{"replaced module", []string{"package"}, NodeData{Label: "secret", Description: str("example.com/secret"), Module: "example.com/secret",
+			Version: "=> /home/alice/secret"}, pseudonym("pkg", "example.com/secret")},
+		{"path", []string{"path"}, NodeData{Label: "secret", Description: str("example.com/secret")}, pseudonym("path", "example.com/secret")},
+		{"interface", []string{"interface"}, NodeData{Label: "Secret", Description: str("example.com/secret.Secret")}, pseudonym("type", "example.com/secret.Secret")},
+		{"struct", []string{"struct"}, NodeData{Label: "Secret", Description: str("example.com/secret.Secret")}, pseudonym("type", "example.com/secret.Secret")},
+		{"type", []string{"type"}, NodeData{Label: "Secret", Parent: "n1"}, pseudonym("type", "n1 Secret")},
+		{"other", []string{"channel"}, NodeData{Label: "secretChan"}, pseudonym("node", "secretChan")},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cg := NewCytoGraph()
+			n := newCytoNode(c.data)
+			n.Data.Id = "n2"
+			n.Data.File = "secret/secret.go"
+			n.Classes = append(n.Classes, c.classes...)
+			cg.Nodes[n.Data.Id] = n
+			cg.Redact()
+			d := n.Data
+			if d.Label != c.label {
+				t.Errorf("got label %q, expected %q", d.Label, c.label)
+			}
+			if d.Name != "" && d.Name != d.Label {
+				t.Errorf("got name %q, expected the pseudonym %q", d.Name, d.Label)
+			}
+			if d.Description != nil && *d.Description != d.Label {
+				t.Errorf("got description %q, expected the pseudonym %q", *d.Description, d.Label)
+			}
+			if d.Signature != "" {
+				t.Errorf("signature %q is not removed", d.Signature)
+			}
+			if c.data.Version != "" && d.Version != "=> "+pseudonym("dir", "/home/alice/secret") {
+				t.Errorf("got version %q, expected the pseudonym of the replacement directory", d.Version)
+			}
+			for _, v := range []string{d.Label, d.Name, d.File, d.Module, d.Version} {
+				if strings.Contains(strings.ToLower(v), "secret") {
+					t.Errorf("redacted node data %q reveals the identifier", v)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactGraph(t *testing.T) {
+	cg := testGraph("a->b")
+	cg.AddReport("cycles", [][]string{{"pkg.a"}})
+	cg.Meta.Packages = []string{"example.com/pkg"}
+	cg.Meta.Partial = true
+	cg.Meta.PartialReason = "could not load pkg.a"
+	cg.Meta.Fallbacks = []string{"pointer: example.com/pkg: type error"}
+	cg.Redact()
+	if len(cg.Reports) != 0 {
+		t.Errorf("reports are not removed: %v", cg.Reports)
+	}
+	if cg.Meta.Packages[0] != pseudonym("pkg", "example.com/pkg") {
+		t.Errorf("got main package %q, expected its pseudonym", cg.Meta.Packages[0])
+	}
+	if !cg.Meta.Partial || cg.Meta.PartialReason != "redacted" {
+		t.Errorf("got partial reason %q, expected it to be redacted", cg.Meta.PartialReason)
+	}
+	if cg.Meta.Fallbacks[0] != "pointer: redacted" {
+		t.Errorf("got fallback %q, expected only the analysis", cg.Meta.Fallbacks[0])
+	}
+	// pseudonyms are stable: graphs redacted separately can still be compared
+	other := testGraph("a->b")
+	other.Redact()
+	if diff := DiffGraphs(cg, other); len(diff.AddedFuncs)+len(diff.RemovedFuncs)+len(diff.AddedCalls)+len(diff.RemovedCalls) > 0 {
+		t.Errorf("redacted graphs of the same program differ: %+v", diff)
+	}
+}
@@ -4,18 +4,141 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/lucasb-eyer/go-colorful"
-	"go/build"
+	"go/ast"
+	"go/doc"
+	"go/token"
 	"go/types"
 	. "golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
 	"hash/fnv"
 	"io"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
+// Granularity is the level of detail the functions are grouped at.
+type Granularity string
+
+const (
+	// FunctionGranularity nests functions in their receiver type, if any, in their package.
+	FunctionGranularity Granularity = "function"
+	// FileGranularity nests functions in their source file, in their package.
+	FileGranularity Granularity = "file"
+	// TypeGranularity aggregates the calls between methods into calls between their receiver types.
+	TypeGranularity Granularity = "type"
+	// PackageGranularity aggregates the calls between functions into calls between their packages.
+	PackageGranularity Granularity = "package"
+)
+
 type RenderOptions struct {
+	// Granularity of the grouping of functions. Function granularity if empty.
+	Granularity       Granularity
 	IncludeGoRoot     bool
 	IncludeUnexported bool
+	// IncludePkg, if not nil, only keeps calls between functions of packages with a matching import path.
+	IncludePkg *regexp.Regexp
+	// ExcludePkg, if not nil, removes calls from and to functions of packages with a matching import path.
+	ExcludePkg *regexp.Regexp
+	// IncludeFunc, if not nil, only keeps calls between functions with a matching fully qualified name.
+	IncludeFunc *regexp.Regexp
+	// ExcludeFunc, if not nil, removes calls from and to functions with a matching fully qualified name.
+	ExcludeFunc *regexp.Regexp
+	// Focus, if not empty, only keeps the calls between the callers and callees within FocusHops calls
+	// of the functions with this name. Fully qualified, or with just the package name, e.g. (*pkg.Server).Serve
+	Focus     string
+	FocusHops int
+	// GatedBy, if not empty, only keeps the calls to functions gated by the feature flag with this accessor name.
+	GatedBy string
+	// NestModules nests the package nodes in nodes of their module.
+	NestModules bool
+	// NestPaths nests the package nodes in nodes of the segments of their import path, to group related packages.
+	NestPaths bool
+	// Condense collapses each strongly connected component of the call graph into a single node.
+	Condense bool
+	// Dominators replaces the calls with the dominator tree: edges from each function to the functions
+	// that can only be reached through it.
+	Dominators bool
+	// Betweenness computes the betweenness centrality of the functions, approximated from the shortest paths
+	// of BetweennessSamples source functions, or exactly if 0.
+	Betweenness        bool
+	BetweennessSamples int
+	// Rank computes the PageRank of the functions, with the calls as links.
+	Rank bool
+	// Clusters assigns the functions to clusters of closely connected functions, regardless of their package.
+	Clusters bool
+	// MergeCalls merges the edges of calls from the same caller to the same callee, at different call sites, into one edge with a count.
+	MergeCalls bool
+	// FoldInstantiations merges the instantiations of each generic function or method into one node, and the calls
+	// from and to them into one edge per caller and callee, with the type arguments they were called with.
+	FoldInstantiations bool
+	// Globals adds the package-level variables, with edges from the functions that read and write them.
+	Globals bool
+	// Channels adds the channels, with edges from the functions that send to and close them, and to the functions
+	// that receive from them.
+	Channels bool
+	// Package, if not empty, only keeps the calls within the package with this import path.
+	Package string
+	// ExcludeDeferred removes the calls of defer statements, which clutter cleanup-heavy code.
+	ExcludeDeferred bool
+	// Goroutines only keeps the calls started as goroutine with a go statement, the concurrency of the program.
+	Goroutines bool
+	// Unsafe only keeps the calls on call paths to functions that use unsafe, to audit the code that can reach them.
+	Unsafe bool
+	// ContextCheck marks the calls of functions that accept a context, from functions that have no context to pass on,
+	// or that pass a new empty context instead of their own, with the warning class.
+	ContextCheck bool
+	// ErrorPaths only keeps the calls between functions that return errors, reachable from exported functions:
+	// the paths errors propagate along, from where they are produced to where they surface at the API.
+	ErrorPaths bool
+	// CrossPackage removes the calls between functions of the same package, to only keep the interactions between packages.
+	CrossPackage bool
+	// WithinModule removes the calls to functions outside of the analyzed module, of the standard library and of other modules.
+	WithinModule bool
+	// ExcludeTestDoubles removes calls from and to mocks, fakes and stubs.
+	ExcludeTestDoubles bool
+	// Modules maps package paths to their module, to annotate nodes of external modules with. Optional.
+	Modules map[string]*packages.Module
+	// Entrypoints maps functions invoked by frameworks to descriptions of their registrations. Optional.
+	Entrypoints map[*ssa.Function][]string
+	// Callbacks maps functions called by the runtime to descriptions of their registrations, e.g. "finalizer". Optional.
+	Callbacks map[*ssa.Function][]string
+	// Tests maps functions run by the go test tool to their kind, e.g. "test", used as class of the node,
+	// and of the edges originating from it. Optional.
+	Tests map[*ssa.Function]string
+	// TestDoubleFiles are the files that declare mocks, fakes or stubs. Optional, methods of types named
+	// like a test double, e.g. MockClient or FakeStore, are recognized regardless.
+	TestDoubleFiles map[string]bool
+	// Constraints maps file names to their build constraints, to annotate the functions declared in them with. Optional.
+	Constraints map[string][]string
+	// PlatformBranches are the position ranges of the branches of if and switch statements on runtime.GOOS or runtime.GOARCH,
+	// to mark the calls in them as platform checked. Optional.
+	PlatformBranches [][2]token.Pos
+	// Platform is the build configuration the call graph was computed for, e.g. "linux/amd64".
+	// Graphs of different platforms can be loaded into the same cyto graph, nodes list the platforms they are part of.
+	Platform string
+	// Repo is the directory of the repository the call graph was computed for, if multiple repositories are merged,
+	// nodes list the repositories they are part of.
+	Repo string
+	// GatedFuncs maps functions to the feature flags they are only reachable through, used as node data. Optional.
+	GatedFuncs map[*ssa.Function][]string
+	// GuardedCalls maps call sites to the feature flags that they depend on, the edges are marked with the flag-guarded class. Optional.
+	GuardedCalls map[ssa.CallInstruction][]string
+	// Spawns maps the functions that start goroutines to their number of go statements,
+	// marked with the spawner class. Optional.
+	Spawns map[*ssa.Function]int
+	// Reflective are the functions that call methods through reflection, of which the callees are not in the graph,
+	// marked with the unsound class. Optional.
+	Reflective map[*ssa.Function]bool
+	// Panicking are the functions that call panic, marked with the panics class. Optional.
+	Panicking map[*ssa.Function]bool
+	// Recovering are the functions that call recover, marked with the recovers class. Optional.
+	Recovering map[*ssa.Function]bool
+	// EscapingPanics are the functions with a panic that can escape to a root of the program,
+	// marked with the panic-escapes class. Optional.
+	EscapingPanics map[*ssa.Function]bool
 }
 
 func isShared(edge *Edge) bool {
@@ -26,9 +149,38 @@ func isSynthetic(edge *Edge) bool {
 	return edge.Callee.Func.Synthetic != ""
 }
 
-func inGoRoot(node *Node) bool {
-	pkg, _ := build.Import(node.Func.Pkg.Pkg.Path(), "", 0)
-	return pkg.Goroot
+// isCgoInternal tells if the edge is a call of a cgo stub into the runtime, to call the C function.
+func isCgoInternal(edge *Edge) bool {
+	_, ok := cFuncName(edge.Caller.Func)
+	return ok
+}
+
+// Origins of packages, relative to the analyzed module, used as node classes.
+const (
+	StdlibOrigin   = "stdlib"
+	ExternalOrigin = "external"
+	InternalOrigin = "internal"
+)
+
+// pkgOrigin classifies the package from the module metadata: packages of the main module are internal,
+// packages of other modules are external. Packages without module, of which the first path element has no dot,
+// unlike the domain of other packages, are part of the standard library. Without module metadata,
+// e.g. in GOPATH mode, other packages are internal.
+func (cg *CytoGraph) pkgOrigin(path string) string {
+	if m, ok := cg.opts.Modules[path]; ok {
+		if m.Main {
+			return InternalOrigin
+		}
+		return ExternalOrigin
+	}
+	first := path
+	if i := strings.Index(path, "/"); i >= 0 {
+		first = path[:i]
+	}
+	if !strings.Contains(first, ".") {
+		return StdlibOrigin
+	}
+	return InternalOrigin
 }
 
 func isUnexported(node *Node) bool {
@@ -36,10 +188,80 @@ func isUnexported(node *Node) bool {
 	return obj != nil && !obj.Exported()
 }
 
+// cyclomaticComplexity computes the complexity of the function from its control flow graph: one plus the number of
+// decisions, i.e. the extra successors of each block. Only blocks reachable from the entry count, so the recover
+// block of functions with deferred calls does not add to it.
+// Functions without a body, e.g. external ones, have no complexity.
+func cyclomaticComplexity(fn *ssa.Function) int {
+	if len(fn.Blocks) == 0 {
+		return 0
+	}
+	complexity := 1
+	seen := map[*ssa.BasicBlock]bool{fn.Blocks[0]: true}
+	work := []*ssa.BasicBlock{fn.Blocks[0]}
+	for len(work) > 0 {
+		b := work[len(work)-1]
+		work = work[:len(work)-1]
+		if len(b.Succs) > 1 {
+			complexity += len(b.Succs) - 1
+		}
+		for _, succ := range b.Succs {
+			if !seen[succ] {
+				seen[succ] = true
+				work = append(work, succ)
+			}
+		}
+	}
+	return complexity
+}
+
+// syntaxLines returns the first and last line of the syntax of the function, or zeros if it has no syntax, e.g. if it is synthetic.
+func syntaxLines(fn *ssa.Function) (start int, end int) {
+	syntax := fn.Syntax()
+	if syntax == nil || !syntax.Pos().IsValid() || !syntax.End().IsValid() {
+		return 0, 0
+	}
+	return fn.Prog.Fset.Position(syntax.Pos()).Line, fn.Prog.Fset.Position(syntax.End()).Line
+}
+
+// docSynopsis returns the first sentence of the doc comment of the function, or an empty string if it has none.
+func docSynopsis(fn *ssa.Function) string {
+	decl, ok := fn.Syntax().(*ast.FuncDecl)
+	if !ok || decl.Doc == nil {
+		return ""
+	}
+	return doc.Synopsis(decl.Doc.Text())
+}
+
 func isGlobal(node *Node) bool {
 	return node.Func.Parent() == nil
 }
 
+// testDoublePrefixes are the name prefixes of mock, fake and stub types.
+var testDoublePrefixes = []string{"Mock", "Fake", "Stub", "mock", "fake", "stub"}
+
+// isTestDoubleType tells if the method receiver is named like a test double, e.g. MockClient, but not Mockingbird.
+func isTestDoubleType(recv *types.Var) bool {
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	name := named.Obj().Name()
+	for _, prefix := range testDoublePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			rest := name[len(prefix):]
+			if rest == "" || (rest[0] >= 'A' && rest[0] <= 'Z') || rest[0] == '_' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type CytoID string
 
 type NodeData struct {
@@ -48,6 +270,76 @@ type NodeData struct {
 	Description *string `json:"description,omitempty"` // optional description
 	Parent      CytoID  `json:"parent"`
 	Color       string  `json:"color"`
+	// function nodes only: fully qualified name, signature and declaration position, to match functions across graphs
+	Name      string `json:"name,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	// Spawns is the number of go statements in the function
+	Spawns int `json:"spawns,omitempty"`
+	// Locks are the sync.Mutex and sync.RWMutex fields and variables the function locks or unlocks,
+	// e.g. "example.com/pkg.Server.mu", to review the lock ordering along call paths
+	Locks []string `json:"locks,omitempty"`
+	// EndLine is the line of the closing brace of the function
+	EndLine int `json:"end_line,omitempty"`
+	// SourceURL links to the declaration, e.g. on GitHub, if a source URL template is given
+	SourceURL string `json:"source_url,omitempty"`
+	// function nodes of external modules, and package nodes of all modules, including the main module
+	Module  string `json:"module,omitempty"`
+	Version string `json:"version,omitempty"`
+	// DocURL links package nodes of dependencies, and of the standard library, to their documentation on pkg.go.dev
+	DocURL string `json:"doc_url,omitempty"`
+	// Entrypoints describes how a function is registered with frameworks that invoke it, e.g. "http GET /users"
+	Entrypoints []string `json:"entrypoints,omitempty"`
+	// Callbacks describes how a function is registered to be called by the runtime, e.g. "finalizer" or "linkname runtime.x"
+	Callbacks []string `json:"callbacks,omitempty"`
+	// Constraints are the GOOS, GOARCH and build tag expressions the file of the function is constrained to
+	Constraints []string `json:"constraints,omitempty"`
+	// Platforms are the build configurations the function was found in, if the graph was loaded for specific platforms
+	Platforms []string `json:"platforms,omitempty"`
+	// Repos are the repositories the function was found in, if the graph was loaded for multiple repositories
+	Repos []string `json:"repos,omitempty"`
+	// FeatureFlags are the accessors of the feature flags the function is only reachable through
+	FeatureFlags []string `json:"feature_flags,omitempty"`
+	// Complexity is the cyclomatic complexity of the function: the number of independent paths through its body
+	Complexity int `json:"complexity,omitempty"`
+	// Lines is the number of source lines the function spans, from its declaration to its closing brace
+	Lines int `json:"lines,omitempty"`
+	// FanIn and FanOut are the numbers of calls to and from the node in the output graph
+	FanIn  int `json:"fan_in,omitempty"`
+	FanOut int `json:"fan_out,omitempty"`
+	// Betweenness is the fraction of the shortest call paths between other functions that go through the function,
+	// if computed
+	Betweenness float64 `json:"betweenness,omitempty"`
+	// Rank is the PageRank of the function, with the calls as links, if computed
+	Rank float64 `json:"rank,omitempty"`
+	// Cluster is the number of the cluster of closely connected functions the function is in, if computed
+	Cluster int `json:"cluster,omitempty"`
+	// supernodes only: the number of functions summarized, per package, and the file with the details of them
+	Count    int            `json:"count,omitempty"`
+	Packages map[string]int `json:"packages,omitempty"`
+	Detail   string         `json:"detail,omitempty"`
+}
+
+func (d *NodeData) addPlatform(platform string) {
+	d.Platforms = appendUnique(d.Platforms, platform)
+}
+
+func (d *NodeData) addRepo(repo string) {
+	d.Repos = appendUnique(d.Repos, repo)
+}
+
+// appendUnique appends the value if it is not empty, and not in the list yet.
+func appendUnique(list []string, v string) []string {
+	if v == "" {
+		return list
+	}
+	for _, x := range list {
+		if x == v {
+			return list
+		}
+	}
+	return append(list, v)
 }
 
 type CytoNode struct {
@@ -59,6 +351,30 @@ type EdgeData struct {
 	Id     CytoID `json:"id"`
 	Source CytoID `json:"source"`
 	Target CytoID `json:"target"`
+	// position of the call site, if known
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Kind is "static" for calls of a known function, and "dynamic" for calls of interface methods and function values
+	Kind string `json:"kind,omitempty"`
+	// Concurrent is true for calls started as goroutine with a go statement, Deferred for calls of defer statements
+	Concurrent bool `json:"concurrent,omitempty"`
+	Deferred   bool `json:"deferred,omitempty"`
+	// Method is the interface method of dynamic calls via an interface
+	Method string `json:"method,omitempty"`
+	// Candidates are the functions the analysis resolved the call site of a dynamic call to, sorted by name,
+	// to see why an edge exists
+	Candidates []string `json:"candidates,omitempty"`
+	// Sites are the positions of the call sites of a merged edge, as file:line, in order
+	Sites []string `json:"sites,omitempty"`
+	// Count is the number of calls a merged or aggregated edge stands for, e.g. between packages
+	Count int `json:"count,omitempty"`
+	// Condition is the kind of the condition the call depends on: error-check, platform-check or flag-check,
+	// and Flag the name of the flag checked, a package-level variable or struct field, or a feature-flag accessor
+	Condition string `json:"condition,omitempty"`
+	Flag      string `json:"flag,omitempty"`
+	// TypeArgs are the type arguments of the instantiations of a generic function an edge of folded instantiations
+	// stands for, e.g. "[int]", sorted
+	TypeArgs []string `json:"type_args,omitempty"`
 }
 
 type CytoEdge struct {
@@ -66,22 +382,54 @@ type CytoEdge struct {
 	Classes []string `json:"classes"`
 }
 
+// GraphMeta describes what a graph was computed from.
+type GraphMeta struct {
+	// Packages are the import paths of the main packages of the program.
+	Packages []string `json:"packages,omitempty"`
+	// Mode is the name of the analysis mode the call graph was computed with.
+	Mode string `json:"mode,omitempty"`
+	// Fallbacks are the more precise analyses that were tried first, and why they were abandoned.
+	Fallbacks []string `json:"fallbacks,omitempty"`
+	// Partial is true if the graph is incomplete, because not all of the call graph could be loaded.
+	Partial bool `json:"partial,omitempty"`
+	// PartialReason explains why the graph is partial.
+	PartialReason string `json:"partial_reason,omitempty"`
+	// Platforms are the build configurations the graph was computed for, if not just the host platform.
+	Platforms []string `json:"platforms,omitempty"`
+	// Repos are the directories of the repositories the graph was computed for, if more than one.
+	Repos []string `json:"repos,omitempty"`
+}
+
 type CytoGraph struct {
 	idCounter uint64
 	idMap     map[string]CytoID
+	// options of the call graph being loaded
+	opts *RenderOptions
+	// order of the nodes in the output
+	sortOrder SortOrder
 	Nodes     map[CytoID]*CytoNode
 	Edges     map[CytoID]*CytoEdge
+	// Reports are extra sections of the JSON output, keyed by section name.
+	Reports map[string]interface{}
+	Meta    GraphMeta
 }
 
 func NewCytoGraph() *CytoGraph {
 	return &CytoGraph{
 		idCounter: 0,
 		idMap:     make(map[string]CytoID),
+		opts:      &RenderOptions{},
 		Nodes:     make(map[CytoID]*CytoNode),
 		Edges:     make(map[CytoID]*CytoEdge),
+		Reports:   make(map[string]interface{}),
 	}
 }
 
+// AddReport adds a section to the JSON output of the graph, replacing any previous report of the same name.
+func (cg *CytoGraph) AddReport(name string, report interface{}) {
+	cg.Reports[name] = report
+}
+
 func (cg *CytoGraph) GetID(fullName string, isNode bool) (isNew bool, id CytoID) {
 	if id, ok := cg.idMap[fullName]; ok {
 		return false, id
@@ -99,7 +447,7 @@ func (cg *CytoGraph) GetID(fullName string, isNode bool) (isNew bool, id CytoID)
 }
 
 func nodeFullName(node *Node) string {
-	return node.Func.RelString(node.Func.Pkg.Pkg)
+	return node.Func.String()
 }
 
 func stringToIntHash(v string) uint32 {
@@ -144,19 +492,26 @@ func signatureToColorHex(signature *types.Signature) string {
 }
 
 func (cg *CytoGraph) ProcessNode(node *Node) CytoID {
-	funcName := nodeFullName(node)
-	fullName := fmt.Sprintf("func ~ %s", funcName)
+	fullName := fmt.Sprintf("func ~ %s", nodeFullName(node))
 	isNew, id := cg.GetID(fullName, true)
-	// just return ID directly if the node already exits
+	// just return ID directly if the node already exits, it may be found for another platform
 	if !isNew {
+		if cNode, ok := cg.Nodes[id]; ok {
+			cNode.Data.addPlatform(cg.opts.Platform)
+			cNode.Data.addRepo(cg.opts.Repo)
+		}
 		return id
 	}
 
 	// node does not exist, create one, with the new id.
-	cNode := &CytoNode{Data: NodeData{Id: id}}
+	cNode := newCytoNode(NodeData{Id: id})
+	cNode.Data.addPlatform(cg.opts.Platform)
+	cNode.Data.addRepo(cg.opts.Repo)
 
-	cNode.Data.Parent = cg.ProcessPkg(node.Func.Pkg.Pkg)
+	pkg := node.Func.Pkg.Pkg
+	cNode.Data.Parent = cg.ProcessPkg(pkg)
 
+	funcName := node.Func.RelString(pkg)
 	if last := strings.LastIndex(funcName, "."); last >= 0 {
 		cNode.Data.Label = funcName[last:]
 	} else {
@@ -165,12 +520,32 @@ func (cg *CytoGraph) ProcessNode(node *Node) CytoID {
 
 	cNode.Data.Color = signatureToColorHex(node.Func.Signature)
 
+	cNode.Data.Name = nodeFullName(node)
+	cNode.Data.Signature = types.TypeString(node.Func.Signature, types.RelativeTo(pkg))
+	if synopsis := docSynopsis(node.Func); synopsis != "" {
+		cNode.Data.Description = &synopsis
+	}
+	if pos := node.Func.Pos(); pos.IsValid() {
+		position := node.Func.Prog.Fset.Position(pos)
+		cNode.Data.File = position.Filename
+		cNode.Data.Line = position.Line
+		cNode.Data.Constraints = cg.opts.Constraints[position.Filename]
+	}
+	cNode.Data.Module, cNode.Data.Version = cg.moduleVersion(pkg)
+	cNode.Data.Complexity = cyclomaticComplexity(node.Func)
+	if start, end := syntaxLines(node.Func); end > 0 {
+		cNode.Data.EndLine = end
+		cNode.Data.Lines = end - start + 1
+	}
+
 	// if it is attached to a type, overwrite the parent node. (type will have package as parent in turn)
 	if recv := node.Func.Signature.Recv(); recv != nil {
 		cNode.Data.Parent = cg.ProcessRecv(recv)
 	}
 
-	if inGoRoot(node) {
+	origin := cg.pkgOrigin(pkg.Path())
+	cNode.Classes = append(cNode.Classes, origin)
+	if origin == StdlibOrigin {
 		cNode.Classes = append(cNode.Classes, "go_root")
 	}
 	if isGlobal(node) {
@@ -179,9 +554,76 @@ func (cg *CytoGraph) ProcessNode(node *Node) CytoID {
 	if isUnexported(node) {
 		cNode.Classes = append(cNode.Classes, "unexported")
 	}
-	// TODO: maybe add (free/local) variables to the graph?
-
+	if eps, ok := cg.opts.Entrypoints[node.Func]; ok {
+		cNode.Data.Entrypoints = eps
+		cNode.Classes = append(cNode.Classes, "entrypoint")
+		for _, ep := range eps {
+			if strings.HasPrefix(ep, "cgo-export") {
+				cNode.Classes = appendUnique(cNode.Classes, "cgo-export")
+			}
+		}
+	}
+	if cbs, ok := cg.opts.Callbacks[node.Func]; ok {
+		cNode.Data.Callbacks = cbs
+		cNode.Classes = append(cNode.Classes, "registered-callback")
+	}
+	if kind, ok := cg.opts.Tests[node.Func]; ok {
+		cNode.Classes = append(cNode.Classes, kind)
+	}
+	if cg.opts.TestDoubleFiles[cNode.Data.File] || (node.Func.Signature.Recv() != nil && isTestDoubleType(node.Func.Signature.Recv())) {
+		cNode.Classes = append(cNode.Classes, "test_double")
+	}
+	if len(cNode.Data.Constraints) > 0 {
+		cNode.Classes = append(cNode.Classes, "constrained")
+	}
+	if spawns := cg.opts.Spawns[node.Func]; spawns > 0 {
+		cNode.Data.Spawns = spawns
+		cNode.Classes = append(cNode.Classes, "spawner")
+	}
+	if locks := funcLocks(node.Func); len(locks) > 0 {
+		cNode.Data.Locks = locks
+		cNode.Classes = append(cNode.Classes, "locks")
+	}
+	if returnsError(node.Func) {
+		cNode.Classes = append(cNode.Classes, "returns-error")
+	}
+	// the cgo stub stands for the C function: control leaves Go there
+	if name, ok := cFuncName(node.Func); ok {
+		cNode.Data.Label = name
+		cNode.Classes = append(cNode.Classes, "c-function")
+	}
+	if callsC(node.Func) {
+		cNode.Classes = append(cNode.Classes, "calls-c")
+	}
+	if loadsPlugins(node.Func) {
+		cNode.Classes = append(cNode.Classes, "dynamic-load")
+	}
+	if usesUnsafe(node.Func) {
+		cNode.Classes = append(cNode.Classes, "unsafe")
+	}
+	if cg.opts.Reflective[node.Func] {
+		cNode.Classes = append(cNode.Classes, "unsound")
+	}
+	if cg.opts.Panicking[node.Func] {
+		cNode.Classes = append(cNode.Classes, "panics")
+	}
+	if cg.opts.Recovering[node.Func] {
+		cNode.Classes = append(cNode.Classes, "recovers")
+	}
+	if cg.opts.EscapingPanics[node.Func] {
+		cNode.Classes = append(cNode.Classes, "panic-escapes")
+	}
+	if flags, ok := cg.opts.GatedFuncs[node.Func]; ok {
+		cNode.Data.FeatureFlags = flags
+		cNode.Classes = append(cNode.Classes, "flag-gated")
+	}
 	cg.Nodes[id] = cNode
+	if cg.opts.Globals {
+		cg.processGlobalAccesses(id, node.Func)
+	}
+	if cg.opts.Channels {
+		cg.processChannelOps(id, node.Func)
+	}
 	return id
 }
 
@@ -195,13 +637,11 @@ func (cg *CytoGraph) ProcessRecv(recv *types.Var) CytoID {
 	}
 
 	// node does not exist, create one, with the new id.
-	cNode := &CytoNode{
-		Data: NodeData{
-			Id:     id,
-			Parent: cg.ProcessPkg(recv.Pkg()),
-			Label:  recv.Type().String(),
-		},
-	}
+	cNode := newCytoNode(NodeData{
+		Id:     id,
+		Parent: cg.ProcessPkg(recv.Pkg()),
+		Label:  recv.Type().String(),
+	})
 
 	cNode.Data.Color = integersToColor(stringToIntHash(cNode.Data.Label)).Hex()
 
@@ -236,22 +676,59 @@ func (cg *CytoGraph) ProcessPkg(pkg *types.Package) CytoID {
 
 	// node does not exist, create one, with the new id.
 	path := pkg.Path()
-	cNode := &CytoNode{
-		Data: NodeData{
-			Id:          id,
-			Label:       pkg.Name(),
-			Description: &path,
-		},
-		Classes: []string{"package"},
-	}
+	cNode := newCytoNode(NodeData{
+		Id:          id,
+		Label:       pkg.Name(),
+		Description: &path,
+	})
+	cNode.Classes = append(cNode.Classes, "package")
 	cNode.Data.Color = integersToColor(stringToIntHash(cNode.Data.Label)).Hex()
+	cNode.Data.Module, cNode.Data.Version = cg.moduleVersion(pkg)
+	origin := cg.pkgOrigin(pkg.Path())
+	cNode.Classes = append(cNode.Classes, origin)
+	cNode.Data.DocURL = docURL(pkg.Path(), origin, cNode.Data.Version)
+	// packages of the main module list it too, to group packages by module
+	if m, ok := cg.opts.Modules[pkg.Path()]; ok && m.Main {
+		cNode.Data.Module = m.Path
+	}
 	cg.Nodes[id] = cNode
 	return id
 }
 
+// docURL returns the pkg.go.dev documentation of dependency packages: of the standard library,
+// or of an external module, at the version that is used. Empty for packages of the main module.
+func docURL(path string, origin string, version string) string {
+	switch {
+	case origin == InternalOrigin:
+		return ""
+	// replacements by local directories have no published documentation of their own
+	case version == "" || strings.HasPrefix(version, "=> "):
+		return "https://pkg.go.dev/" + path
+	default:
+		return "https://pkg.go.dev/" + path + "@" + version
+	}
+}
+
+// moduleVersion returns the path and version of the external module the package is part of, if known.
+// Replaced modules are reported with the version (or local path) of the replacement.
+func (cg *CytoGraph) moduleVersion(pkg *types.Package) (path string, version string) {
+	m, ok := cg.opts.Modules[pkg.Path()]
+	if !ok || m.Main {
+		return "", ""
+	}
+	if r := m.Replace; r != nil {
+		if r.Version == "" {
+			return m.Path, "=> " + r.Path
+		}
+		return m.Path, r.Version
+	}
+	return m.Path, m.Version
+}
+
 func (cg *CytoGraph) ProcessEdge(edge *Edge) CytoID {
-	fullName := fmt.Sprintf("call @%d ~ %s -> %s",
-		edge.Pos(), nodeFullName(edge.Caller), nodeFullName(edge.Callee))
+	// the position, not the token.Pos, identifies the call site in call graphs of different programs
+	fullName := fmt.Sprintf("call @%s ~ %s -> %s",
+		edge.Caller.Func.Prog.Fset.Position(edge.Pos()), nodeFullName(edge.Caller), nodeFullName(edge.Callee))
 	isNew, id := cg.GetID(fullName, true)
 	// just return ID directly if the node already exits
 	if !isNew {
@@ -262,54 +739,265 @@ func (cg *CytoGraph) ProcessEdge(edge *Edge) CytoID {
 	idCaller := cg.ProcessNode(edge.Caller)
 	idCallee := cg.ProcessNode(edge.Callee)
 
-	cEdge := &CytoEdge{
-		Data: EdgeData{
-			Id:     id,
-			Source: idCaller,
-			Target: idCallee,
-		},
-		// description precisely says what kind of edge this is, e.g. "concurrent static function closure call"
-		Classes: strings.Split(edge.Description(), " "),
+	cEdge := newCytoEdge(EdgeData{
+		Id:     id,
+		Source: idCaller,
+		Target: idCallee,
+	})
+	if pos := edge.Pos(); pos.IsValid() {
+		position := edge.Caller.Func.Prog.Fset.Position(pos)
+		cEdge.Data.File = position.Filename
+		cEdge.Data.Line = position.Line
+	}
+	// description precisely says what kind of edge this is, e.g. "concurrent static function closure call"
+	cEdge.Classes = append(cEdge.Classes, strings.Split(edge.Description(), " ")...)
+	// the same, as fields, for tools that read the graph
+	if edge.Site != nil {
+		cEdge.Data.Kind = "dynamic"
+		if edge.Site.Common().StaticCallee() != nil {
+			cEdge.Data.Kind = "static"
+		}
+		if cEdge.Data.Kind == "dynamic" {
+			if common := edge.Site.Common(); common.IsInvoke() {
+				cEdge.Data.Method = common.Method.FullName()
+			}
+			cEdge.Data.Candidates = candidates(edge)
+		}
+		switch edge.Site.(type) {
+		case *ssa.Go:
+			cEdge.Data.Concurrent = true
+		case *ssa.Defer:
+			cEdge.Data.Deferred = true
+		}
+	}
+	if kind, ok := cg.opts.Tests[edge.Caller.Func]; ok {
+		cEdge.Classes = append(cEdge.Classes, kind)
+	}
+	if _, ok := cFuncName(edge.Callee.Func); ok {
+		cEdge.Classes = append(cEdge.Classes, "cgo")
+	}
+	if isPluginLoad(edge.Callee.Func) {
+		cEdge.Classes = append(cEdge.Classes, "dynamic-load")
+	}
+	if edge.Site != nil {
+		cEdge.Data.Condition, cEdge.Data.Flag = callCondition(edge.Site, cg.opts.PlatformBranches)
+	}
+	if flags, ok := cg.opts.GuardedCalls[edge.Site]; ok {
+		cEdge.Classes = append(cEdge.Classes, "flag-guarded")
+		cEdge.Data.Condition, cEdge.Data.Flag = FlagCheck, flags[0]
+	}
+	if cEdge.Data.Condition != "" {
+		cEdge.Classes = append(cEdge.Classes, "conditional", cEdge.Data.Condition)
+	}
+	if cg.opts.ContextCheck {
+		if warning := contextWarning(edge); warning != "" {
+			cEdge.Classes = append(cEdge.Classes, warning, "warning")
+		}
 	}
 	cg.Edges[id] = cEdge
 	return id
 }
 
+// candidates returns the names of the callees of the call site of the edge, in the call graph, sorted.
+func candidates(edge *Edge) []string {
+	var names []string
+	for _, e := range edge.Caller.Out {
+		if e.Site == edge.Site {
+			names = appendUnique(names, nodeFullName(e.Callee))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadCallGraph adds the calls of the call graph to the cyto graph. The graph is not filtered: call graphs of multiple
+// analyses can be loaded into the same cyto graph, which is then filtered once with Filter.
 func (cg *CytoGraph) LoadCallGraph(g *Graph, opts *RenderOptions) error {
+	cg.opts = opts
 	g.DeleteSyntheticNodes()
 
-	return GraphVisitEdges(g, func(edge *Edge) error {
-
-		if isSynthetic(edge) || isShared(edge) {
-			return nil
-		}
-
-		if !opts.IncludeGoRoot && inGoRoot(edge.Callee) {
-			return nil
-		}
+	err := visitEdgesSorted(g, func(edge *Edge) error {
 
-		if !opts.IncludeUnexported && isUnexported(edge.Callee) {
+		if isSynthetic(edge) || isShared(edge) || isCgoInternal(edge) {
 			return nil
 		}
 
 		cg.ProcessEdge(edge)
 		return nil
 	})
+	return err
+}
+
+// visitEdgesSorted visits the edges of the graph ordered by caller name, call site position and callee name,
+// so the IDs are assigned in the same order every time, unlike the map order of GraphVisitEdges.
+func visitEdgesSorted(g *Graph, edge func(*Edge) error) error {
+	nodes := make([]*Node, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if a, b := sortName(nodes[i]), sortName(nodes[j]); a != b {
+			return a < b
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	for _, n := range nodes {
+		out := append([]*Edge(nil), n.Out...)
+		sort.SliceStable(out, func(i, j int) bool {
+			if out[i].Pos() != out[j].Pos() {
+				return out[i].Pos() < out[j].Pos()
+			}
+			return sortName(out[i].Callee) < sortName(out[j].Callee)
+		})
+		for _, e := range out {
+			if err := edge(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortName is the full name of the node, or empty for the root node of call graphs without a root function.
+func sortName(n *Node) string {
+	if n.Func == nil {
+		return ""
+	}
+	return nodeFullName(n)
 }
 
 type CytoJsonOut struct {
-	Nodes []*CytoNode `json:"nodes"`
-	Edges []*CytoEdge `json:"edges"`
+	Nodes   []*CytoNode            `json:"nodes"`
+	Edges   []*CytoEdge            `json:"edges"`
+	Reports map[string]interface{} `json:"reports,omitempty"`
+	Meta    *GraphMeta             `json:"meta,omitempty"`
 }
 
+// ReadJson reads a graph, as written by WriteJson. Call graphs loaded into it afterwards are not merged with it.
+func ReadJson(r io.Reader) (*CytoGraph, error) {
+	// nodes and edges are decoded one by one, to draw them from the pools, like rendered graphs.
+	var in struct {
+		Nodes   []json.RawMessage      `json:"nodes"`
+		Edges   []json.RawMessage      `json:"edges"`
+		Reports map[string]interface{} `json:"reports,omitempty"`
+		Meta    *GraphMeta             `json:"meta,omitempty"`
+	}
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+	cg := NewCytoGraph()
+	for _, raw := range in.Nodes {
+		n := newCytoNode(NodeData{})
+		if err := json.Unmarshal(raw, n); err != nil {
+			nodePool.Put(n)
+			cg.Release()
+			return nil, err
+		}
+		cg.Nodes[n.Data.Id] = n
+		cg.skipID(n.Data.Id)
+	}
+	for _, raw := range in.Edges {
+		e := newCytoEdge(EdgeData{})
+		if err := json.Unmarshal(raw, e); err != nil {
+			edgePool.Put(e)
+			cg.Release()
+			return nil, err
+		}
+		cg.Edges[e.Data.Id] = e
+		cg.skipID(e.Data.Id)
+	}
+	for name, report := range in.Reports {
+		cg.Reports[name] = report
+	}
+	if in.Meta != nil {
+		cg.Meta = *in.Meta
+	}
+	return cg, nil
+}
+
+// skipID makes sure new IDs do not collide with the given existing ID.
+func (cg *CytoGraph) skipID(id CytoID) {
+	if len(id) < 2 {
+		return
+	}
+	if v, err := strconv.ParseUint(string(id[1:]), 16, 64); err == nil && v > cg.idCounter {
+		cg.idCounter = v
+	}
+}
+
+// The nodes and edges are written in the order of their IDs, or in the sort order of the graph, for deterministic output.
 func (cg *CytoGraph) WriteJson(w io.Writer) error {
-	out := CytoJsonOut{}
+	enc := json.NewEncoder(w)
+	return enc.Encode(cg.jsonOut())
+}
+
+// WritePrettyJson writes the graph like WriteJson, but indented, and with the keys of all objects sorted,
+// for graphs that are reviewed as text, e.g. checked in and diffed.
+func (cg *CytoGraph) WritePrettyJson(w io.Writer) error {
+	data, err := json.Marshal(cg.jsonOut())
+	if err != nil {
+		return err
+	}
+	// decoded objects are maps, which are encoded with sorted keys
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(tree)
+}
+
+func (cg *CytoGraph) jsonOut() *CytoJsonOut {
+	cg.countFans()
+	out := &CytoJsonOut{Reports: cg.Reports, Meta: &cg.Meta}
 	for _, n := range cg.Nodes {
 		out.Nodes = append(out.Nodes, n)
 	}
 	for _, e := range cg.Edges {
 		out.Edges = append(out.Edges, e)
 	}
-	enc := json.NewEncoder(w)
-	return enc.Encode(out)
+	cg.sortNodes(out.Nodes)
+	cg.sortEdges(out.Edges, out.Nodes)
+	return out
+}
+
+// nonCallClasses are the classes of edges between functions and other nodes that are no calls.
+var nonCallClasses = []string{"implements", "reads", "writes", "sends", "receives", "closes"}
+
+// isCall tells if the edge is a call, or a relation derived from calls, e.g. between packages.
+func isCall(e *CytoEdge) bool {
+	for _, c := range nonCallClasses {
+		if e.HasClass(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// countFans sets the fan-in and fan-out of each node to its number of incoming and outgoing call edges,
+// so they match the graph as written, after all filtering.
+func (cg *CytoGraph) countFans() {
+	for _, n := range cg.Nodes {
+		n.Data.FanIn, n.Data.FanOut = 0, 0
+	}
+	for _, e := range cg.Edges {
+		if !isCall(e) {
+			continue
+		}
+		if n, ok := cg.Nodes[e.Data.Source]; ok {
+			n.Data.FanOut++
+		}
+		if n, ok := cg.Nodes[e.Data.Target]; ok {
+			n.Data.FanIn++
+		}
+	}
+}
+
+// lessID orders IDs by their counter, shorter hexadecimal counters are lower.
+func lessID(a CytoID, b CytoID) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
 }
@@ -0,0 +1,131 @@
+package render
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// benchFuncs is the number of functions in the synthetic program. Every pair of functions gets at most one edge,
+// so the largest graph has benchFuncs*benchFuncs edges.
+const benchFuncs = 1000
+
+var benchEdgeCounts = []int{10_000, 100_000, 1_000_000}
+
+// benchProgram builds a program of functions with a variety of signatures, for nodes to be colored differently.
+func benchProgram(b *testing.B) []*ssa.Function {
+	var src strings.Builder
+	src.WriteString("package bench\n")
+	paramTypes := []string{"int", "string", "error", "[]byte", "map[string]int", "*int"}
+	for i := 0; i < benchFuncs; i++ {
+		p := paramTypes[i%len(paramTypes)]
+		r := paramTypes[(i/len(paramTypes))%len(paramTypes)]
+		fmt.Fprintf(&src, "func F%d(a %s) (r %s) { return }\n", i, p, r)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "bench.go", src.String(), 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	pkg := types.NewPackage("example.com/bench", "bench")
+	ssaPkg, _, err := ssautil.BuildPackage(&types.Config{}, fset, pkg, []*ast.File{f}, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	funcs := make([]*ssa.Function, benchFuncs)
+	for i := range funcs {
+		funcs[i] = ssaPkg.Func(fmt.Sprintf("F%d", i))
+	}
+	return funcs
+}
+
+// benchCallGraph connects the functions with the given number of distinct edges.
+func benchCallGraph(funcs []*ssa.Function, edges int) (*callgraph.Graph, []*callgraph.Edge) {
+	g := callgraph.New(nil)
+	nodes := make([]*callgraph.Node, len(funcs))
+	for i, fn := range funcs {
+		nodes[i] = g.CreateNode(fn)
+	}
+	n := len(funcs)
+	for k := 0; k < edges; k++ {
+		callgraph.AddEdge(nodes[k%n], nil, nodes[(k/n+k%n)%n])
+	}
+	var all []*callgraph.Edge
+	for _, node := range nodes {
+		all = append(all, node.Out...)
+	}
+	return g, all
+}
+
+func BenchmarkProcessNode(b *testing.B) {
+	funcs := benchProgram(b)
+	g, _ := benchCallGraph(funcs, 0)
+	nodes := make([]*callgraph.Node, 0, len(funcs))
+	for _, fn := range funcs {
+		nodes = append(nodes, g.Nodes[fn])
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cg := NewCytoGraph()
+		for _, node := range nodes {
+			cg.ProcessNode(node)
+		}
+		cg.Release()
+	}
+	b.ReportMetric(float64(len(nodes)), "nodes/op")
+}
+
+func BenchmarkProcessEdge(b *testing.B) {
+	funcs := benchProgram(b)
+	for _, count := range benchEdgeCounts {
+		b.Run(fmt.Sprintf("edges=%d", count), func(b *testing.B) {
+			if count > 100_000 && testing.Short() {
+				b.Skip("large graph skipped in short mode")
+			}
+			_, edges := benchCallGraph(funcs, count)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cg := NewCytoGraph()
+				for _, e := range edges {
+					cg.ProcessEdge(e)
+				}
+				cg.Release()
+			}
+			b.ReportMetric(float64(len(edges)), "edges/op")
+		})
+	}
+}
+
+func BenchmarkWriteJson(b *testing.B) {
+	funcs := benchProgram(b)
+	for _, count := range benchEdgeCounts {
+		b.Run(fmt.Sprintf("edges=%d", count), func(b *testing.B) {
+			if count > 100_000 && testing.Short() {
+				b.Skip("large graph skipped in short mode")
+			}
+			_, edges := benchCallGraph(funcs, count)
+			cg := NewCytoGraph()
+			for _, e := range edges {
+				cg.ProcessEdge(e)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := cg.WriteJson(ioutil.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
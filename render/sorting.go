@@ -0,0 +1,150 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SortOrder is the order nodes are written in, and listed in the outline and text output.
+type SortOrder string
+
+const (
+	// IDOrder is the order the nodes were added to the graph in, the default.
+	IDOrder SortOrder = "id"
+	// NameOrder sorts by fully qualified function name, or by label for other nodes.
+	// Names are compared bytewise, not by locale: the order is the same on every machine.
+	NameOrder SortOrder = "name"
+	// PackageOrder sorts by package path, and by name within a package.
+	PackageOrder SortOrder = "package"
+	// The metric orders sort by the node data of the same name, highest first, ties are sorted by name.
+	FanInOrder       SortOrder = "fan-in"
+	FanOutOrder      SortOrder = "fan-out"
+	ComplexityOrder  SortOrder = "complexity"
+	LinesOrder       SortOrder = "lines"
+	RankOrder        SortOrder = "rank"
+	BetweennessOrder SortOrder = "betweenness"
+)
+
+// ParseSortOrder maps a sort order name, as used on the command line, to a sort order.
+func ParseSortOrder(name string) (SortOrder, error) {
+	switch o := SortOrder(name); o {
+	case IDOrder, NameOrder, PackageOrder, FanInOrder, FanOutOrder, ComplexityOrder, LinesOrder, RankOrder, BetweennessOrder:
+		return o, nil
+	}
+	return "", fmt.Errorf("sort order not recognized: %q", name)
+}
+
+// SetSortOrder changes the order the nodes are output in. Edges follow the order of their source and target nodes.
+func (cg *CytoGraph) SetSortOrder(order SortOrder) {
+	cg.sortOrder = order
+}
+
+// metric returns the node data to sort by for metric orders.
+func (o SortOrder) metric(n *CytoNode) float64 {
+	switch o {
+	case FanInOrder:
+		return float64(n.Data.FanIn)
+	case FanOutOrder:
+		return float64(n.Data.FanOut)
+	case ComplexityOrder:
+		return float64(n.Data.Complexity)
+	case LinesOrder:
+		return float64(n.Data.Lines)
+	case RankOrder:
+		return n.Data.Rank
+	case BetweennessOrder:
+		return n.Data.Betweenness
+	}
+	return 0
+}
+
+// sortNodes sorts the nodes in the sort order of the graph. Ties are broken by ID, so the order is always deterministic.
+func (cg *CytoGraph) sortNodes(nodes []*CytoNode) {
+	order := cg.sortOrder
+	if order == FanInOrder || order == FanOutOrder {
+		cg.countFans()
+	}
+	name := func(n *CytoNode) string {
+		if n.Data.Name != "" {
+			return n.Data.Name
+		}
+		return n.Data.Label
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		switch order {
+		case "", IDOrder:
+		case NameOrder:
+			if name(a) != name(b) {
+				return name(a) < name(b)
+			}
+		case PackageOrder:
+			if pa, pb := cg.nodePackage(a), cg.nodePackage(b); pa != pb {
+				return pa < pb
+			}
+			if name(a) != name(b) {
+				return name(a) < name(b)
+			}
+		default:
+			if ma, mb := order.metric(a), order.metric(b); ma != mb {
+				return ma > mb
+			}
+			if name(a) != name(b) {
+				return name(a) < name(b)
+			}
+		}
+		return lessID(a.Data.Id, b.Data.Id)
+	})
+}
+
+// sortListed sorts function nodes listed as text by name, or in the sort order of the graph if one is set,
+// as the order they were added in means nothing to readers.
+func (cg *CytoGraph) sortListed(nodes []*CytoNode) {
+	if cg.sortOrder == "" || cg.sortOrder == IDOrder {
+		sort.Slice(nodes, func(i, j int) bool {
+			if nodes[i].Data.Name != nodes[j].Data.Name {
+				return nodes[i].Data.Name < nodes[j].Data.Name
+			}
+			return lessID(nodes[i].Data.Id, nodes[j].Data.Id)
+		})
+		return
+	}
+	cg.sortNodes(nodes)
+}
+
+// listOrder returns the position of each function node in the order of sortListed,
+// to sort many lists of node IDs without sorting the nodes again for each of them.
+func (cg *CytoGraph) listOrder() map[CytoID]int {
+	nodes := make([]*CytoNode, 0, len(cg.Nodes))
+	for _, n := range cg.Nodes {
+		nodes = append(nodes, n)
+	}
+	cg.sortListed(nodes)
+	order := make(map[CytoID]int, len(nodes))
+	for i, n := range nodes {
+		order[n.Data.Id] = i
+	}
+	return order
+}
+
+// sortEdges sorts the edges by the position of their source node in the sorted nodes, then by their target node.
+func (cg *CytoGraph) sortEdges(edges []*CytoEdge, nodes []*CytoNode) {
+	if cg.sortOrder == "" || cg.sortOrder == IDOrder {
+		sort.Slice(edges, func(i, j int) bool { return lessID(edges[i].Data.Id, edges[j].Data.Id) })
+		return
+	}
+	index := make(map[CytoID]int, len(nodes))
+	for i, n := range nodes {
+		index[n.Data.Id] = i
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if index[a.Data.Source] != index[b.Data.Source] {
+			return index[a.Data.Source] < index[b.Data.Source]
+		}
+		if index[a.Data.Target] != index[b.Data.Target] {
+			return index[a.Data.Target] < index[b.Data.Target]
+		}
+		return lessID(a.Data.Id, b.Data.Id)
+	})
+}
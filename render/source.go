@@ -0,0 +1,65 @@
+package render
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// SourceLines reads lines of source files, for context in text reports. Files are read once, and cached.
+type SourceLines struct {
+	files map[string][]string
+}
+
+func NewSourceLines() *SourceLines {
+	return &SourceLines{files: make(map[string][]string)}
+}
+
+// Line returns the line, 1-based, of the file, without surrounding whitespace,
+// or an empty string if the file cannot be read, or is too short.
+func (s *SourceLines) Line(file string, line int) string {
+	lines, ok := s.files[file]
+	if !ok {
+		// unreadable files are cached as empty too, they are not retried
+		if data, err := ioutil.ReadFile(file); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		s.files[file] = lines
+	}
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
+
+// LinkSources sets the source URL of the function nodes to the link of their declaration, if it is not empty.
+func (cg *CytoGraph) LinkSources(link func(file string, line int, endLine int) string) {
+	for _, n := range cg.Nodes {
+		if n.Data.File != "" {
+			n.Data.SourceURL = link(n.Data.File, n.Data.Line, n.Data.EndLine)
+		}
+	}
+}
+
+// firstCallSites maps each pair of source and target node to the edge between them with the first call site,
+// to look up the call sites of many calls at once.
+func (cg *CytoGraph) firstCallSites() map[[2]CytoID]*CytoEdge {
+	sites := make(map[[2]CytoID]*CytoEdge)
+	for _, e := range cg.Edges {
+		key := [2]CytoID{e.Data.Source, e.Data.Target}
+		first, ok := sites[key]
+		if !ok || e.Data.File < first.Data.File || (e.Data.File == first.Data.File && e.Data.Line < first.Data.Line) {
+			sites[key] = e
+		}
+	}
+	return sites
+}
+
+// sourceContext formats the position and source line of the call site of the edge, e.g. "main.go:12: srv.Serve()",
+// or returns an empty string if the position is unknown.
+func sourceContext(src *SourceLines, e *CytoEdge) string {
+	if e == nil || e.Data.File == "" {
+		return ""
+	}
+	return e.Data.File + ":" + strconv.Itoa(e.Data.Line) + ": " + src.Line(e.Data.File, e.Data.Line)
+}
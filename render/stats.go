@@ -0,0 +1,164 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// PackageCount is the number of functions of a package in the graph.
+type PackageCount struct {
+	Package string `json:"package"`
+	Funcs   int    `json:"funcs"`
+}
+
+// GraphStats summarizes the size and shape of a call graph.
+type GraphStats struct {
+	Funcs    int            `json:"funcs"`
+	Calls    int            `json:"calls"`
+	Packages []PackageCount `json:"packages"`
+	// InDegrees and OutDegrees map numbers of calls to and from a function to the number of functions with that many.
+	InDegrees  map[int]int `json:"in_degrees"`
+	OutDegrees map[int]int `json:"out_degrees"`
+	// MaxDepth is the largest number of calls on the shortest call paths from the functions without callers.
+	MaxDepth int `json:"max_depth"`
+	Static   int `json:"static"`
+	Dynamic  int `json:"dynamic"`
+	// FanInHubs and FanOutHubs are the functions with the most calls to and from them, most first, if requested.
+	FanInHubs  []Hub `json:"fan_in_hubs,omitempty"`
+	FanOutHubs []Hub `json:"fan_out_hubs,omitempty"`
+}
+
+// Hub is a function with many calls to or from it.
+type Hub struct {
+	Func   string `json:"func"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	FanIn  int    `json:"fan_in"`
+	FanOut int    `json:"fan_out"`
+}
+
+// Stats computes the statistics of the graph, with the given number of hubs with the highest fan-in and fan-out.
+// Packages are sorted by function count, most first.
+func (cg *CytoGraph) Stats(hubs int) *GraphStats {
+	cg.countFans()
+	stats := &GraphStats{
+		Calls:      len(cg.Edges),
+		InDegrees:  make(map[int]int),
+		OutDegrees: make(map[int]int),
+	}
+	byPkg := make(map[string]int)
+	var entries []CytoID
+	for id, n := range cg.Nodes {
+		if n.Data.Name == "" {
+			continue
+		}
+		stats.Funcs++
+		byPkg[cg.nodePackage(n)]++
+		stats.InDegrees[n.Data.FanIn]++
+		stats.OutDegrees[n.Data.FanOut]++
+		if n.Data.FanIn == 0 {
+			entries = append(entries, id)
+		}
+	}
+	for pkg, count := range byPkg {
+		stats.Packages = append(stats.Packages, PackageCount{Package: pkg, Funcs: count})
+	}
+	sort.Slice(stats.Packages, func(i, j int) bool {
+		if stats.Packages[i].Funcs != stats.Packages[j].Funcs {
+			return stats.Packages[i].Funcs > stats.Packages[j].Funcs
+		}
+		return stats.Packages[i].Package < stats.Packages[j].Package
+	})
+	out, _ := cg.adjacency()
+	for _, d := range hopDistances(entries, out, -1) {
+		if d > stats.MaxDepth {
+			stats.MaxDepth = d
+		}
+	}
+	stats.FanInHubs = cg.hubs(hubs, func(h *Hub) int { return h.FanIn })
+	stats.FanOutHubs = cg.hubs(hubs, func(h *Hub) int { return h.FanOut })
+	for _, e := range cg.Edges {
+		if e.HasClass("static") {
+			stats.Static++
+		} else if e.HasClass("dynamic") {
+			stats.Dynamic++
+		}
+	}
+	return stats
+}
+
+// hubs returns the top function nodes with the highest degree, most first, as counted by countFans.
+// None if top is 0 or less.
+func (cg *CytoGraph) hubs(top int, degree func(h *Hub) int) []Hub {
+	if top <= 0 {
+		return nil
+	}
+	var out []Hub
+	for _, n := range cg.Nodes {
+		if n.Data.Name != "" {
+			out = append(out, Hub{Func: n.Data.Name, File: n.Data.File, Line: n.Data.Line, FanIn: n.Data.FanIn, FanOut: n.Data.FanOut})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if di, dj := degree(&out[i]), degree(&out[j]); di != dj {
+			return di > dj
+		}
+		return out[i].Func < out[j].Func
+	})
+	if len(out) > top {
+		out = out[:top]
+	}
+	return out
+}
+
+// WriteText writes the statistics, and the degree distributions as counts per degree.
+func (s *GraphStats) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "functions: %d\ncalls: %d (static: %d, dynamic: %d)\nmax depth: %d\n",
+		s.Funcs, s.Calls, s.Static, s.Dynamic, s.MaxDepth); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "packages: %d\n", len(s.Packages)); err != nil {
+		return err
+	}
+	for _, p := range s.Packages {
+		if _, err := fmt.Fprintf(w, "  %6d %s\n", p.Funcs, p.Package); err != nil {
+			return err
+		}
+	}
+	for _, dist := range []struct {
+		name    string
+		degrees map[int]int
+	}{{"fan-in", s.InDegrees}, {"fan-out", s.OutDegrees}} {
+		if _, err := fmt.Fprintf(w, "%s distribution (calls: functions):\n", dist.name); err != nil {
+			return err
+		}
+		degrees := make([]int, 0, len(dist.degrees))
+		for d := range dist.degrees {
+			degrees = append(degrees, d)
+		}
+		sort.Ints(degrees)
+		for _, d := range degrees {
+			if _, err := fmt.Fprintf(w, "  %6d: %d\n", d, dist.degrees[d]); err != nil {
+				return err
+			}
+		}
+	}
+	for _, hubs := range []struct {
+		name string
+		hubs []Hub
+	}{{"fan-in", s.FanInHubs}, {"fan-out", s.FanOutHubs}} {
+		if len(hubs.hubs) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "top %s hubs (in, out):\n", hubs.name); err != nil {
+			return err
+		}
+		for _, h := range hubs.hubs {
+			if _, err := fmt.Fprintf(w, "  %6d %6d %s %s:%d\n", h.FanIn, h.FanOut, h.Func, h.File, h.Line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
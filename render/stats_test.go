@@ -0,0 +1,27 @@
+package render
+
+import "testing"
+
+func TestStatsHubs(t *testing.T) {
+	cases := []struct {
+		top   int
+		fanIn []string
+	}{
+		{-1, nil},
+		{0, nil},
+		{1, []string{"pkg.c"}},
+		{2, []string{"pkg.c", "pkg.b"}},
+		{10, []string{"pkg.c", "pkg.b", "pkg.a"}},
+	}
+	for _, c := range cases {
+		stats := testGraph("a->b", "a->c", "b->c").Stats(c.top)
+		if len(stats.FanInHubs) != len(c.fanIn) || len(stats.FanOutHubs) != len(c.fanIn) {
+			t.Fatalf("top %d: got %d fan-in and %d fan-out hubs, expected %d", c.top, len(stats.FanInHubs), len(stats.FanOutHubs), len(c.fanIn))
+		}
+		for i, h := range stats.FanInHubs {
+			if h.Func != c.fanIn[i] {
+				t.Errorf("top %d: got fan-in hub %d %q, expected %q", c.top, i, h.Func, c.fanIn[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Supernode summarizes a call subtree: a function, and the functions only called through it.
+type Supernode struct {
+	Node *CytoNode
+	// Detail is the graph of the functions of the subtree, and the calls between them.
+	Detail *CytoGraph
+}
+
+// Summarize replaces the largest call subtrees of at most threshold functions with a supernode each.
+// A subtree is a function, and all functions it dominates: the functions that are only called through it.
+// The supernode is labeled after the top function, and counts the functions per package.
+// Calls from and to the subtree are moved to the supernode, calls within it are moved to its detail graph.
+// Subtrees of a single function are not summarized.
+func (cg *CytoGraph) Summarize(threshold int) []Supernode {
+	idom := cg.dominators()
+	children := make(map[CytoID][]CytoID)
+	for id, parent := range idom {
+		children[parent] = append(children[parent], id)
+	}
+	size := make(map[CytoID]int)
+	var count func(id CytoID) int
+	count = func(id CytoID) int {
+		if n, ok := size[id]; ok {
+			return n
+		}
+		n := 1
+		for _, c := range children[id] {
+			n += count(c)
+		}
+		size[id] = n
+		return n
+	}
+	var tops []CytoID
+	for id, parent := range idom {
+		// only the largest subtrees within the threshold: the parent subtree is too large, or is the virtual root
+		if s := count(id); s > 1 && s <= threshold && (parent == "" || count(parent) > threshold) {
+			tops = append(tops, id)
+		}
+	}
+	sort.Slice(tops, func(i, j int) bool { return lessID(tops[i], tops[j]) })
+
+	super := make(map[CytoID]CytoID)
+	var out []Supernode
+	for _, top := range tops {
+		topNode, ok := cg.Nodes[top]
+		if !ok {
+			continue
+		}
+		members := []CytoID{top}
+		for i := 0; i < len(members); i++ {
+			members = append(members, children[members[i]]...)
+		}
+
+		_, id := cg.GetID("supernode ~ "+string(top), true)
+		s := Supernode{Node: newCytoNode(NodeData{
+			Id:       id,
+			Label:    fmt.Sprintf("%s (+%d)", topNode.Data.Label, len(members)-1),
+			Name:     topNode.Data.Name,
+			Parent:   topNode.Data.Parent,
+			Color:    topNode.Data.Color,
+			Count:    len(members),
+			Packages: make(map[string]int),
+		}), Detail: NewCytoGraph()}
+		s.Node.Classes = append(s.Node.Classes, "supernode")
+		s.Detail.Meta = cg.Meta
+		for _, m := range members {
+			n, ok := cg.Nodes[m]
+			if !ok {
+				continue
+			}
+			s.Node.Data.Packages[cg.nodePackage(n)]++
+			// the detail graph also needs the parents to nest the function in
+			for p := n; p != nil; p = cg.Nodes[p.Data.Parent] {
+				s.Detail.Nodes[p.Data.Id] = p
+				if p.Data.Parent == "" {
+					break
+				}
+			}
+			super[m] = id
+		}
+		cg.Nodes[id] = s.Node
+		out = append(out, s)
+	}
+
+	internal := cg.contract(super)
+	for i := range out {
+		for _, e := range internal[out[i].Node.Data.Id] {
+			out[i].Detail.Edges[e.Data.Id] = e
+		}
+	}
+	cg.Prune()
+	return out
+}
+
+// contract moves the calls from and to the nodes to the group nodes they are mapped to, and removes the mapped nodes.
+// Calls between the same nodes are merged into the first one by ID. The calls within a group are removed,
+// and returned per group node.
+func (cg *CytoGraph) contract(groups map[CytoID]CytoID) map[CytoID][]*CytoEdge {
+	internal := make(map[CytoID][]*CytoEdge)
+	calls := make(map[[2]CytoID]bool)
+	ids := make([]CytoID, 0, len(cg.Edges))
+	for id := range cg.Edges {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	for _, id := range ids {
+		e := cg.Edges[id]
+		src, srcOk := groups[e.Data.Source]
+		dst, dstOk := groups[e.Data.Target]
+		if !srcOk && !dstOk {
+			continue
+		}
+		delete(cg.Edges, id)
+		if srcOk && dstOk && src == dst {
+			internal[src] = append(internal[src], e)
+			continue
+		}
+		if srcOk {
+			e.Data.Source = src
+		}
+		if dstOk {
+			e.Data.Target = dst
+		}
+		key := [2]CytoID{e.Data.Source, e.Data.Target}
+		if !calls[key] {
+			calls[key] = true
+			cg.Edges[id] = e
+		}
+	}
+	for m := range groups {
+		delete(cg.Nodes, m)
+	}
+	return internal
+}
@@ -0,0 +1,54 @@
+package render
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+func isUnsafePointer(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Kind() == types.UnsafePointer
+}
+
+// usesUnsafe tells if the function handles unsafe.Pointer values, e.g. to convert between pointer types,
+// or to do pointer arithmetic. Uses of unsafe.Sizeof and the like are constants, and not recognized.
+func usesUnsafe(fn *ssa.Function) bool {
+	for _, p := range fn.Params {
+		if isUnsafePointer(p.Type()) {
+			return true
+		}
+	}
+	var operands []*ssa.Value
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if v, ok := instr.(ssa.Value); ok && isUnsafePointer(v.Type()) {
+				return true
+			}
+			for _, op := range instr.Operands(operands[:0]) {
+				if *op != nil && isUnsafePointer((*op).Type()) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// keepUnsafe removes the edges that are not on a call path to a function that uses unsafe,
+// leaving the code that can reach unsafe code, to audit.
+func (cg *CytoGraph) keepUnsafe() {
+	var start []CytoID
+	for id, n := range cg.Nodes {
+		if n.HasClass("unsafe") {
+			start = append(start, id)
+		}
+	}
+	_, in := cg.adjacency()
+	reaching := hopDistances(start, in, -1)
+	for id, e := range cg.Edges {
+		if _, ok := reaching[e.Data.Target]; !ok {
+			delete(cg.Edges, id)
+		}
+	}
+}
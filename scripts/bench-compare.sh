@@ -0,0 +1,21 @@
+#!/bin/sh
+# Compares the render benchmarks of a base revision with the working tree, using benchstat.
+#
+# Usage: scripts/bench-compare.sh [base revision (default HEAD)] [benchmark regex (default .)]
+#
+# Install benchstat with: go get golang.org/x/perf/cmd/benchstat
+# Set BENCH_COUNT to change the number of runs per benchmark (default 6), more runs give more reliable statistics.
+set -e
+
+base=${1:-HEAD}
+bench=${2:-.}
+count=${BENCH_COUNT:-6}
+
+tmp=$(mktemp -d)
+trap 'git worktree remove --force "$tmp/base" >/dev/null 2>&1; rm -rf "$tmp"' EXIT
+
+git worktree add --detach "$tmp/base" "$base" >/dev/null
+(cd "$tmp/base" && go test ./render -run '^$' -bench "$bench" -benchmem -count "$count") > "$tmp/old.txt"
+go test ./render -run '^$' -bench "$bench" -benchmem -count "$count" > "$tmp/new.txt"
+
+benchstat "$tmp/old.txt" "$tmp/new.txt"
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/protolambda/gocyto/render"
+)
+
+const serveUsage = `
+Serve the web view of pre-generated graph JSON files, e.g. published by CI.
+Graphs are loaded from local files, http(s):// URLs, or public s3://bucket/key and gs://bucket/key objects,
+cached, and revalidated (with the ETag of URLs) when they are requested after -revalidate.
+
+Usage:
+
+gocyto serve [options...] <graph location(s)>
+
+Options:
+
+`
+
+var serveIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en"><head><meta charset="utf-8"/><title>Go call graphs</title></head>
+<body><ul>{{range $i, $loc := .}}<li><a href="/graph/{{$i}}">{{$loc}}</a></li>{{end}}</ul></body></html>
+`))
+
+func serveCmd(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addrFlag := flags.String("addr", "localhost:8080", "Address to listen on")
+	revalidateFlag := flags.Duration("revalidate", time.Minute, "Age after which a cached graph is checked for changes when requested")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, serveUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	tmpl, err := template.ParseFiles("index.gohtml")
+	check(err, "could not parse index.gohtml: %v")
+	renderPage := func(cytoGraph *render.CytoGraph) ([]byte, error) {
+		var buf bytes.Buffer
+		err := writeWebPage(&buf, tmpl, cytoGraph)
+		return buf.Bytes(), err
+	}
+	graphs := make([]*cachedGraph, 0, flags.NArg())
+	for _, location := range flags.Args() {
+		graphs = append(graphs, newCachedGraph(location, *revalidateFlag, renderPage))
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := serveIndex.Execute(w, flags.Args()); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "could not write index: %v\n", err)
+		}
+	})
+	mux.HandleFunc("/graph/", func(w http.ResponseWriter, r *http.Request) {
+		i, err := strconv.Atoi(r.URL.Path[len("/graph/"):])
+		if err != nil || i < 0 || i >= len(graphs) {
+			http.NotFound(w, r)
+			return
+		}
+		page, err := graphs[i].get()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if _, err := w.Write(page); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "could not write graph page: %v\n", err)
+		}
+	})
+	_, _ = fmt.Fprintf(os.Stderr, "serving %d graphs on http://%s\n", len(graphs), *addrFlag)
+	check(http.ListenAndServe(*addrFlag, mux), "could not serve: %v")
+}
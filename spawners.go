@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/protolambda/gocyto/analysis"
+)
+
+const spawnersUsage = `
+List the functions of the packages that start goroutines, with the number of go statements in them,
+and whether they are reachable from the roots of the program.
+
+Usage:
+
+gocyto spawners [options...] <package path(s)>
+
+Options:
+
+`
+
+func spawnersCmd(args []string) {
+	flags := flag.NewFlagSet("spawners", flag.ExitOnError)
+	var af analysisFlags
+	af.register(flags)
+	var pf profileFlags
+	pf.register(flags)
+	jsonFlag := flags.Bool("json", false, "Output the spawners as JSON instead of text")
+	outFlag := flags.String("out", "", "Output file, if none is specified, output to std out")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, spawnersUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	stopProfiles := pf.start()
+	defer stopProfiles()
+
+	aProg, _, callGraph, failure := computeCallGraph(&af, af.analysisMode(), nil, af.singleDir(), flags.Args())
+	if failure != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: %v, the static call graph is used, reachable functions may be reported as unreachable\n", failure)
+	}
+	spawners, err := analysis.FindSpawners(aProg, callGraph)
+	check(err, "could not find goroutine spawners: %v")
+	output(*outFlag, func(w io.Writer) {
+		if *jsonFlag {
+			check(json.NewEncoder(w).Encode(spawners), "could not write spawners JSON: %v")
+		} else {
+			check(analysis.WriteSpawnersText(w, spawners), "could not write spawners: %v")
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sourceLinker expands a source URL template, e.g. https://github.com/org/repo/blob/{commit}/{file}#L{line},
+// for files in git repositories: the file path is relative to the root of the repository,
+// and the commit is the one checked out.
+type sourceLinker struct {
+	template string
+	// repos caches the git repository of each directory, the zero value if the directory is not in one
+	repos map[string]gitRepo
+}
+
+type gitRepo struct {
+	root   string
+	commit string
+}
+
+func newSourceLinker(template string) *sourceLinker {
+	return &sourceLinker{template: template, repos: make(map[string]gitRepo)}
+}
+
+func (l *sourceLinker) repo(dir string) gitRepo {
+	if r, ok := l.repos[dir]; ok {
+		return r
+	}
+	var r gitRepo
+	if out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel", "HEAD").Output(); err == nil {
+		if lines := strings.Split(strings.TrimSpace(string(out)), "\n"); len(lines) == 2 {
+			r = gitRepo{root: lines[0], commit: lines[1]}
+		}
+	}
+	l.repos[dir] = r
+	return r
+}
+
+// link returns the URL of the lines of the file, or an empty string if the file is not in a git repository.
+func (l *sourceLinker) link(file string, line int, endLine int) string {
+	// git resolves symlinks in the root it returns
+	if resolved, err := filepath.EvalSymlinks(file); err == nil {
+		file = resolved
+	}
+	r := l.repo(filepath.Dir(file))
+	if r.root == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(r.root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	if endLine == 0 {
+		endLine = line
+	}
+	return strings.NewReplacer(
+		"{commit}", r.commit,
+		"{file}", filepath.ToSlash(rel),
+		"{line}", strconv.Itoa(line),
+		"{endLine}", strconv.Itoa(endLine),
+	).Replace(l.template)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/protolambda/gocyto/render"
+)
+
+const statsUsage = `
+Print statistics of a graph: function and call counts, functions per package, fan-in and fan-out distributions,
+the maximum call depth, the numbers of static and dynamic calls, and the hubs: the functions with the highest fan-in and fan-out.
+
+Usage:
+
+gocyto stats [options...] <graph.json>
+gocyto stats [options...] <package path(s)>
+
+Options:
+
+`
+
+func statsCmd(args []string) {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	var af analysisFlags
+	af.register(flags)
+	var pf profileFlags
+	pf.register(flags)
+	hubsFlag := flags.Int("hubs", 10, "Number of functions with the highest fan-in, and with the highest fan-out, to list. None if 0 or less")
+	jsonFlag := flags.Bool("json", false, "Output the statistics as JSON instead of text")
+	outFlag := flags.String("out", "", "Output file, if none is specified, output to std out")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, statsUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	stopProfiles := pf.start()
+	defer stopProfiles()
+
+	var cytoGraph *render.CytoGraph
+	if flags.NArg() == 1 && strings.HasSuffix(flags.Arg(0), ".json") {
+		var err error
+		cytoGraph, err = readGraphFile(flags.Arg(0))
+		check(err, "could not read graph: %v")
+	} else {
+		// count everything in the program
+		opts := &render.RenderOptions{
+			IncludeGoRoot:     true,
+			IncludeUnexported: true,
+		}
+		cytoGraph = analyzeGraph(flags, &af, flags.Args(), opts)
+	}
+	stats := cytoGraph.Stats(*hubsFlag)
+	output(*outFlag, func(w io.Writer) {
+		if *jsonFlag {
+			check(json.NewEncoder(w).Encode(stats), "could not write statistics JSON: %v")
+		} else {
+			check(stats.WriteText(w), "could not write statistics: %v")
+		}
+	})
+}
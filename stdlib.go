@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/protolambda/gocyto/analysis"
+)
+
+const stdlibUsage = `
+List the standard library packages and functions the packages call directly,
+with the number of calls and their positions. E.g. for portability reviews.
+
+Usage:
+
+gocyto stdlib [options...] <package path(s)>
+
+Options:
+
+`
+
+func stdlibCmd(args []string) {
+	flags := flag.NewFlagSet("stdlib", flag.ExitOnError)
+	var af analysisFlags
+	flags.BoolVar(&af.tests, "tests", false, "Load the test files of the packages too")
+	flags.Var(&af.queryDirs, "query-dir", "Directory to query from for go packages. Current dir if empty")
+	flags.StringVar(&af.build, "build", "", "Build flags to pass to Go build tool. Separated with spaces")
+	jsonFlag := flags.Bool("json", false, "Output the usage as JSON instead of text")
+	outFlag := flags.String("out", "", "Output file, if none is specified, output to std out")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, stdlibUsage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	aProg, err := analysis.RunAnalysis(af.tests, af.buildFlags(), flags.Args(), af.singleDir())
+	check(err, "could not run program analysis: %v")
+	usage := analysis.FindStdlibUsage(aProg)
+	output(*outFlag, func(w io.Writer) {
+		if *jsonFlag {
+			check(json.NewEncoder(w).Encode(usage), "could not write stdlib usage JSON: %v")
+		} else {
+			check(usage.WriteText(w), "could not write stdlib usage: %v")
+		}
+	})
+}